@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// setupLogging makes a logger with the configured level and format the default, which also
+// sends the standard logger through it. Gin only prints its route table and warnings at the debug level.
+func setupLogging(cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	// The level was validated when the config was loaded
+	_ = level.UnmarshalText([]byte(cfg.Level))
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, options)
+	}
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+
+	if level > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	return logger
+}
+
+// fatal logs msg with its attributes at the error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -1,40 +1,193 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/emirhanalptekin/vinylvault/internal/api"
 	"github.com/emirhanalptekin/vinylvault/internal/config"
 	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/metrics"
+	"github.com/emirhanalptekin/vinylvault/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	_ "github.com/emirhanalptekin/vinylvault/docs"
 )
 
 // @title VinylVault API
 // @version 1.0
-// @description A REST API for managing vinyl record collections
+// @description A REST API for managing vinyl record collections.
+// @description Errors are returned as RFC 7807 problem details (application/problem+json) with a stable "code" member.
 // @host localhost:8080
 // @BasePath /
 // @schemes http
 func main() {
+	storeKind := flag.String("store", "database", "data store to use: database (from db_url) or memory")
+	seedSets := flag.String("seed", "", "comma-separated fixture sets to load before serving, e.g. demo")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: vinylvault [flags] [serve | migrate up | down [steps] | status | version | seed]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
 	// Load configuration
-	cfg := config.GetAppConfig("internal/config/config.yml")
+	cfg, err := config.GetAppConfig("internal/config/config.yml")
+	if err != nil {
+		// Without a configuration, log the way the defaults would
+		setupLogging(config.LogConfig{Level: config.DefaultLogLevel, Format: config.DefaultLogFormat})
+		fatal("Unable to load configuration", "error", err)
+	}
+	logger := setupLogging(cfg.Log)
+
+	switch command := flag.Arg(0); command {
+	case "", "serve":
+		serve(cfg, logger, *storeKind, *seedSets)
+	case "migrate":
+		runMigrate(cfg, flag.Args()[1:])
+	case "seed":
+		runSeed(cfg, flag.Args()[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// tracingFlushTimeout limits how long the server waits for the last spans to be exported
+const tracingFlushTimeout = 5 * time.Second
+
+// serve runs the API server
+func serve(cfg *config.Config, logger *slog.Logger, storeKind, seedSets string) {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Unable to set up tracing", "error", err)
+	}
+	serverMetrics := metrics.New()
 
-	// Initialize database connection
-	db.InitializeDB(cfg.DatabaseUrl)
+	// Initialize the data store
+	var store db.Store
+	switch storeKind {
+	case "database":
+		store = openDatabase(cfg, serverMetrics.QueryTracer(), tracing.QueryTracer())
+	case "memory":
+		slog.Warn("Using the in-memory store, data will be lost when the server stops")
+		store = db.NewMemoryStore()
+	default:
+		fatal("Unknown store, use database or memory", "store", storeKind)
+	}
 
-	// Set up Gin router
-	router := gin.Default()
+	if err := prepareSchema(context.Background(), store, cfg.AutoMigrate); err != nil {
+		fatal("Refusing to start", "error", err)
+	}
+
+	if seedSets != "" {
+		if err := loadFixtures(context.Background(), store, strings.Split(seedSets, ",")); err != nil {
+			fatal("Seeding failed", "error", err)
+		}
+	}
+
+	// Set up Gin router. Every request gets a span, an ID and a log line; panics are logged with that ID.
+	router := gin.New()
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
+	router.Use(api.RequestID(logger), serverMetrics.Middleware(), api.AccessLog(), api.Recovery())
+	router.Use(api.CORS(cfg.CORS))
+	router.Use(api.QueryTimeout(cfg.Server.QueryTimeout))
 
 	// Register API routes
-	api.RegisterRoutes(router)
+	api.RegisterRoutes(router, store)
+
+	// Prometheus metrics
+	serverMetrics.RegisterStore(store)
+	router.GET("/metrics", gin.WrapH(serverMetrics.Handler()))
+
+	server := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
 
 	// Start the server
-	log.Printf("Starting server on port %s...\n", cfg.Port)
-	log.Printf("Swagger UI available at http://localhost:%s/swagger/index.html\n", cfg.Port)
-	err := router.Run(":" + cfg.Port)
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		scheme = "https"
+	}
+	slog.Info("Starting server", "port", cfg.Port, "tls", cfg.TLS.Enabled(),
+		"swagger", fmt.Sprintf("%s://localhost:%s/swagger/index.html", scheme, cfg.Port))
+	if err := runServer(server, cfg.TLS, cfg.Server.ShutdownTimeout); err != nil {
+		slog.Error("Server stopped with an error", "error", err)
+	}
+
+	if err := store.Close(); err != nil {
+		slog.Error("Unable to close the data store", "error", err)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Unable to flush traces", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// openDatabase opens the store behind db_url, exiting if it can't be reached.
+// The tracers are told about every Postgres query.
+func openDatabase(cfg *config.Config, tracers ...pgx.QueryTracer) db.Store {
+	store, err := db.Open(context.Background(), cfg.DatabaseUrl, db.ConnectOptions{
+		Attempts:        cfg.Database.ConnectAttempts,
+		MaxWait:         cfg.Database.ConnectMaxWait,
+		MinConns:        cfg.Database.MinConns,
+		MaxConns:        cfg.Database.MaxConns,
+		MaxConnLifetime: cfg.Database.MaxConnLifetime,
+		MaxConnIdleTime: cfg.Database.MaxConnIdleTime,
+		Tracers:         tracers,
+	})
 	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("Unable to open database", "error", err)
+	}
+	return store
+}
+
+// runServer serves, over HTTPS if tls is enabled, until SIGINT or SIGTERM. It then stops
+// accepting connections and gives in-flight requests up to shutdownTimeout to finish.
+func runServer(server *http.Server, tls config.TLSConfig, shutdownTimeout time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if tls.Enabled() {
+			serveErr <- server.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// A second signal kills the process right away
+	stop()
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", shutdownTimeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("requests were still running after %s: %w", shutdownTimeout, err)
 	}
+	return nil
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+)
+
+const migrateUsage = "usage: vinylvault migrate up | down [steps] | status | version"
+
+// errMigrateUsage reports a migrate command that doesn't match migrateUsage
+var errMigrateUsage = errors.New(migrateUsage)
+
+// runMigrate implements the migrate command against the database in the configuration.
+// The store is closed before exiting on an error.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	store := openDatabase(cfg)
+	err := migrate(context.Background(), store, args)
+	store.Close()
+
+	if errors.Is(err, errMigrateUsage) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal("Migration failed", "command", args[0], "error", err)
+	}
+}
+
+// migrate runs one migrate subcommand, printing its outcome
+func migrate(ctx context.Context, store db.Store, args []string) error {
+	migrator, err := storeMigrator(store)
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("Applied %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Printf("Already at the latest version, %d\n", migrator.Latest())
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migrations to revert")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("reading the schema version: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS")
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", status.Version, status.Name, state)
+		}
+		w.Flush()
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return fmt.Errorf("reading the schema version: %w", err)
+		}
+		fmt.Println(version)
+	default:
+		return errMigrateUsage
+	}
+	return nil
+}
+
+// storeMigrator returns the migration runner of stores with a schema
+func storeMigrator(store db.Store) (*db.Migrator, error) {
+	migratable, ok := store.(db.Migratable)
+	if !ok {
+		return nil, errors.New("this store has no schema to migrate")
+	}
+	return migratable.Migrator()
+}
+
+// prepareSchema makes sure the database schema matches this binary before the server starts,
+// applying pending migrations first when autoMigrate is set
+func prepareSchema(ctx context.Context, store db.Store, autoMigrate bool) error {
+	if _, ok := store.(db.Migratable); !ok {
+		return nil
+	}
+
+	migrator, err := storeMigrator(store)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("Applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err = migrator.CheckVersion(ctx)
+	if errors.Is(err, db.ErrSchemaOutdated) {
+		return fmt.Errorf("%w; run `vinylvault migrate up` or set auto_migrate", err)
+	}
+	return err
+}
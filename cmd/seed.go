@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/seed"
+)
+
+// runSeed implements the seed command against the database in the configuration
+func runSeed(cfg *config.Config, args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	synthetic := flags.Int("synthetic", 0, "number of synthetic albums to generate for load testing")
+	randomSeed := flags.Uint64("random-seed", 1, "seed for the synthetic data, the same seed generates the same albums")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: vinylvault seed [-synthetic N] [-random-seed S] [fixture set ...]")
+		fmt.Fprintln(flags.Output(), "fixture sets:", strings.Join(seed.Names(), ", "))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 && *synthetic == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	store := openDatabase(cfg)
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := prepareSchema(ctx, store, cfg.AutoMigrate); err != nil {
+		fatal("Refusing to seed", "error", err)
+	}
+
+	if err := loadFixtures(ctx, store, flags.Args()); err != nil {
+		fatal("Seeding failed", "error", err)
+	}
+
+	if *synthetic > 0 {
+		result, err := seed.Apply(ctx, store, seed.Synthetic(*synthetic, *randomSeed))
+		if err != nil {
+			fatal("Seeding failed", "error", err)
+		}
+		slog.Info("Seeded synthetic albums", "albums", *synthetic, "created", result.Created, "skipped", result.Skipped)
+	}
+}
+
+// loadFixtures applies the named fixture sets to the store
+func loadFixtures(ctx context.Context, store db.Store, names []string) error {
+	for _, name := range names {
+		fixture, err := seed.Load(name)
+		if err != nil {
+			return err
+		}
+		result, err := seed.Apply(ctx, store, fixture)
+		if err != nil {
+			return fmt.Errorf("fixture set %q: %w", name, err)
+		}
+		slog.Info("Seeded fixture set", "set", name, "created", result.Created, "skipped", result.Skipped)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType is the media type of RFC 7807 problem details
+const problemContentType = "application/problem+json"
+
+// statusClientClosedRequest is the non-standard status nginx uses for requests
+// whose client disconnected before the response was ready
+const statusClientClosedRequest = 499
+
+// Stable, machine-readable error codes. Not-found codes are derived from the
+// resource name, e.g. "album_not_found".
+const (
+	codeInvalidBody          = "invalid_body"
+	codeInvalidParameter     = "invalid_parameter"
+	codeInvalidQuery         = "invalid_query"
+	codeInvalidPatch         = "invalid_patch"
+	codeValidationFailed     = "validation_failed"
+	codeInvalidReference     = "invalid_reference"
+	codeInvalidValue         = "invalid_value"
+	codeAlreadyExists        = "already_exists"
+	codeHasAlbums            = "has_albums"
+	codePatchTestFailed      = "patch_test_failed"
+	codePreconditionFailed   = "precondition_failed"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeRouteNotFound        = "route_not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeQueryTimeout         = "query_timeout"
+	codeInternalError        = "internal_error"
+)
+
+// respondProblem writes an RFC 7807 problem details response.
+// The problem type is derived from the code, so clients can switch on either.
+func respondProblem(c *gin.Context, status int, code, title, detail string, fieldErrors ...models.FieldError) {
+	c.Header("Content-Type", problemContentType)
+	c.JSON(status, models.Problem{
+		Type:     "/problems/" + strings.ReplaceAll(code, "_", "-"),
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+		Errors:   fieldErrors,
+	})
+}
+
+// respondParameterError reports an invalid query parameter. Mistakes in an album query
+// get their own code, and the message points at the offending token.
+func respondParameterError(c *gin.Context, err error) {
+	var queryErr *db.AlbumQueryError
+	if errors.As(err, &queryErr) {
+		respondProblem(c, http.StatusBadRequest, codeInvalidQuery, "Invalid album query", err.Error(),
+			models.FieldError{Field: "query", Message: queryErr.Message})
+		return
+	}
+	respondProblem(c, http.StatusBadRequest, codeInvalidParameter, "Invalid query parameter", err.Error())
+}
+
+// respondNotFound reports that the requested resource, e.g. "album", doesn't exist
+func respondNotFound(c *gin.Context, resource string) {
+	title := strings.ToUpper(resource[:1]) + resource[1:] + " not found"
+	respondProblem(c, http.StatusNotFound, resource+"_not_found", title, "")
+}
+
+// respondInternalError reports an unexpected failure without leaking its details to the client;
+// err is logged with the request ID instead. Failures caused by the request running out of time
+// are reported as a 504, and requests whose client has gone away get no body at all.
+func respondInternalError(c *gin.Context, err error, title string) {
+	ctx := c.Request.Context()
+	logger := LoggerFromContext(ctx)
+
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		logger.WarnContext(ctx, title, "error", err, "code", codeQueryTimeout)
+		respondProblem(c, http.StatusGatewayTimeout, codeQueryTimeout, title, "The data store didn't answer in time")
+	case errors.Is(ctxErr, context.Canceled):
+		logger.InfoContext(ctx, title, "error", err, "reason", "client closed the request")
+		c.AbortWithStatus(statusClientClosedRequest)
+	default:
+		logger.ErrorContext(ctx, title, "error", err, "code", codeInternalError)
+		respondProblem(c, http.StatusInternalServerError, codeInternalError, title, "")
+	}
+}
+
+// respondDBError writes the response for an error returned by the db package.
+// Constraint violations become 409 or 422 responses naming the offending field,
+// missing records become a 404 for the resource and anything else becomes a 500 with failure.
+func respondDBError(c *gin.Context, err error, resource, failure string) {
+	var constraintErr *db.ConstraintError
+	switch {
+	case errors.As(err, &constraintErr):
+		status, code, message := describeConstraintError(constraintErr)
+		respondProblem(c, status, code, failure, message,
+			models.FieldError{Field: constraintErr.Field, Message: message})
+	case errors.Is(err, db.ErrNotFound):
+		respondNotFound(c, resource)
+	default:
+		respondInternalError(c, err, failure)
+	}
+}
+
+// describeConstraintError picks the status code, error code and message for a constraint violation
+func describeConstraintError(err *db.ConstraintError) (int, string, string) {
+	switch {
+	case errors.Is(err, db.ErrConflict):
+		return http.StatusConflict, codeAlreadyExists, fmt.Sprintf("A record with this %s already exists", err.Field)
+	case errors.Is(err, db.ErrInvalidReference):
+		return http.StatusUnprocessableEntity, codeInvalidReference, fmt.Sprintf("%s does not reference an existing record", err.Field)
+	default:
+		return http.StatusUnprocessableEntity, codeInvalidValue, fmt.Sprintf("%s has an invalid value", err.Field)
+	}
+}
+
+// NoRoute answers requests for unknown routes with a problem response
+func NoRoute(c *gin.Context) {
+	respondProblem(c, http.StatusNotFound, codeRouteNotFound, "Route not found", "")
+}
+
+// NoMethod answers requests with an unsupported method with a problem response
+func NoMethod(c *gin.Context) {
+	respondProblem(c, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed", "")
+}
@@ -1,40 +1,94 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/health"
 	"github.com/emirhanalptekin/vinylvault/internal/models"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
-// Health check endpoint
+// Handler serves the API endpoints on top of a Store
+type Handler struct {
+	store db.Store
+}
+
+// NewHandler creates a Handler that reads and writes through the given store
+func NewHandler(store db.Store) *Handler {
+	return &Handler{store: store}
+}
+
+// Health check endpoint, also served as the liveness probe. It never touches the store,
+// so a database outage doesn't get the process restarted.
 // @Summary Health check
 // @Description Check if the API is running
 // @Tags system
 // @Produce json
 // @Success 200 {object} map[string]string
 // @Router / [get]
+// @Router /healthz [get]
 func HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// Readiness returns the readiness probe handler, which runs the checker's checks
+// @Summary Readiness check
+// @Description Check that the API can serve requests: the database answers, its schema is up to date and the connection pool statistics
+// @Tags system
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func Readiness(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
 // GetAlbums handles GET /albums request
-// @Summary Get all albums
-// @Description Retrieve all albums in the collection
+// @Summary Get albums
+// @Description Retrieve a page of albums, optionally filtered and sorted
 // @Tags albums
 // @Produce json
-// @Success 200 {array} models.Album
-// @Failure 500 {object} models.ErrorResponse
+// @Param limit query int false "Page size (1-200)" default(50)
+// @Param offset query int false "Number of albums to skip" default(0)
+// @Param sort query string false "Sort field" Enums(title, artist, release_year, rating, condition) default(title)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param artist_id query string false "Only albums by this artist"
+// @Param genre_id query string false "Only albums in this genre"
+// @Param condition query string false "Comma-separated list of conditions"
+// @Param min_rating query int false "Minimum rating"
+// @Param max_rating query int false "Maximum rating"
+// @Param min_year query int false "Earliest release year"
+// @Param max_year query int false "Latest release year"
+// @Param query query string false "Album query, e.g. artist:\"pink floyd\" year:1970..1979 rating:>=4 -notes:reissue"
+// @Success 200 {object} models.AlbumPage
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /albums [get]
-func GetAlbums(c *gin.Context) {
-	albums, err := db.GetAlbums()
+func (h *Handler) GetAlbums(c *gin.Context) {
+	filter, err := parseAlbumFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve albums"})
+		respondParameterError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, albums)
+
+	page, err := h.store.GetAlbums(c.Request.Context(), filter)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve albums")
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 // GetAlbumByID handles GET /albums/:id request
@@ -44,20 +98,20 @@ func GetAlbums(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Album ID"
 // @Success 200 {object} models.Album
-// @Failure 404 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /albums/{id} [get]
-func GetAlbumByID(c *gin.Context) {
+func (h *Handler) GetAlbumByID(c *gin.Context) {
 	id := c.Param("id")
 
-	album, err := db.GetAlbumByID(id)
+	album, err := h.store.GetAlbumByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve album"})
+		respondInternalError(c, err, "Failed to retrieve album")
 		return
 	}
 
 	if album == nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Album not found"})
+		respondNotFound(c, "album")
 		return
 	}
 
@@ -72,13 +126,15 @@ func GetAlbumByID(c *gin.Context) {
 // @Produce json
 // @Param album body models.Album true "Album Data"
 // @Success 201 {object} map[string]string
-// @Failure 400 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /albums [post]
-func CreateAlbum(c *gin.Context) {
+func (h *Handler) CreateAlbum(c *gin.Context) {
 	var album models.Album
 	if err := c.ShouldBindJSON(&album); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid album data"})
+		respondBindError(c, err, "Invalid album data")
 		return
 	}
 
@@ -87,8 +143,8 @@ func CreateAlbum(c *gin.Context) {
 		album.ID = "alb-" + uuid.New().String()[:8]
 	}
 
-	if err := db.CreateAlbum(album); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to create album"})
+	if err := h.store.CreateAlbum(c.Request.Context(), album); err != nil {
+		respondDBError(c, err, "album", "Failed to create album")
 		return
 	}
 
@@ -97,36 +153,151 @@ func CreateAlbum(c *gin.Context) {
 
 // UpdateAlbum handles PUT /albums/:id request
 // @Summary Update an album
-// @Description Update an existing album's information
+// @Description Update an existing album's information.
+// @Description With an "If-None-Match: *" header the album is created instead, and 412 is returned if it already exists.
 // @Tags albums
 // @Accept json
 // @Produce json
 // @Param id path string true "Album ID"
+// @Param If-None-Match header string false "Set to * to create the album only if it doesn't exist yet"
 // @Param album body models.Album true "Album Data"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} models.ErrorResponse
-// @Failure 500 {object} models.ErrorResponse
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /albums/{id} [put]
-func UpdateAlbum(c *gin.Context) {
+func (h *Handler) UpdateAlbum(c *gin.Context) {
 	id := c.Param("id")
 
 	var album models.Album
 	if err := c.ShouldBindJSON(&album); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid album data"})
+		respondBindError(c, err, "Invalid album data")
 		return
 	}
 
 	// Ensure the ID in the path matches the ID in the body
 	album.ID = id
 
-	if err := db.UpdateAlbum(album); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update album"})
+	// "If-None-Match: *" asks for the album to be created, but only if it doesn't exist yet
+	if c.GetHeader("If-None-Match") == "*" {
+		if err := h.store.CreateAlbum(c.Request.Context(), album); err != nil {
+			if errors.Is(err, db.ErrConflict) {
+				respondProblem(c, http.StatusPreconditionFailed, codePreconditionFailed, "Album already exists",
+					"If-None-Match: * only creates albums that don't exist yet")
+				return
+			}
+			respondDBError(c, err, "album", "Failed to create album")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"id": album.ID})
+		return
+	}
+
+	if err := h.store.UpdateAlbum(c.Request.Context(), album); err != nil {
+		respondDBError(c, err, "album", "Failed to update album")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Album updated successfully"})
 }
 
+// PatchAlbum handles PATCH /albums/:id request
+// @Summary Partially update an album
+// @Description Change only the supplied fields of an album. Accepts an RFC 7396 merge patch
+// @Description (application/merge-patch+json or application/json) or an RFC 6902 JSON Patch (application/json-patch+json).
+// @Description In a merge patch, null clears the notes; the other fields can't be removed.
+// @Description A JSON Patch is only written if the album still has the values it tested, otherwise it fails with 409.
+// @Tags albums
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path string true "Album ID"
+// @Param patch body models.AlbumPatch true "Fields to change"
+// @Success 200 {object} models.Album
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /albums/{id} [patch]
+func (h *Handler) PatchAlbum(c *gin.Context) {
+	id := c.Param("id")
+
+	body, err := c.GetRawData()
+	if err != nil {
+		respondProblem(c, http.StatusBadRequest, codeInvalidPatch, "Invalid patch", "The request body couldn't be read")
+		return
+	}
+
+	album, err := h.store.GetAlbumByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to update album")
+		return
+	}
+	if album == nil {
+		respondNotFound(c, "album")
+		return
+	}
+
+	// expected holds the current values a JSON Patch was tested against
+	var patch, expected models.AlbumPatch
+	switch c.ContentType() {
+	case mergePatchContentType, binding.MIMEJSON, "":
+		patch, err = mergePatchAlbum(body)
+	case jsonPatchContentType:
+		patch, expected, err = jsonPatchAlbum(album, body)
+	default:
+		respondProblem(c, http.StatusUnsupportedMediaType, codeUnsupportedMediaType, "Unsupported patch format",
+			"Use "+mergePatchContentType+" or "+jsonPatchContentType)
+		return
+	}
+	if err != nil {
+		if errors.Is(err, errPatchTestFailed) {
+			respondProblem(c, http.StatusConflict, codePatchTestFailed, "Patch test failed", err.Error())
+			return
+		}
+		respondProblem(c, http.StatusBadRequest, codeInvalidPatch, "Invalid patch", err.Error())
+		return
+	}
+	if err := binding.Validator.ValidateStruct(patch); err != nil {
+		respondBindError(c, err, "Invalid album data")
+		return
+	}
+
+	if patch.IsEmpty() {
+		c.JSON(http.StatusOK, album)
+		return
+	}
+
+	if err := h.store.PatchAlbum(c.Request.Context(), id, patch, expected); err != nil {
+		if errors.Is(err, db.ErrModified) {
+			respondProblem(c, http.StatusConflict, codePatchTestFailed, "Patch test failed",
+				"The album changed while the patch was applied; read it again and retry")
+			return
+		}
+		respondDBError(c, err, "album", "Failed to update album")
+		return
+	}
+
+	// Return the album as stored, including the (possibly new) artist and genre
+	album, err = h.store.GetAlbumByID(c.Request.Context(), id)
+	if err == nil && album == nil {
+		err = fmt.Errorf("album %s disappeared after it was patched", id)
+	}
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve album")
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
 // DeleteAlbum handles DELETE /albums/:id request
 // @Summary Delete an album
 // @Description Remove an album from the collection
@@ -134,13 +305,14 @@ func UpdateAlbum(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Album ID"
 // @Success 200 {object} map[string]string
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /albums/{id} [delete]
-func DeleteAlbum(c *gin.Context) {
+func (h *Handler) DeleteAlbum(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := db.DeleteAlbum(id); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete album"})
+	if err := h.store.DeleteAlbum(c.Request.Context(), id); err != nil {
+		respondDBError(c, err, "album", "Failed to delete album")
 		return
 	}
 
@@ -153,30 +325,691 @@ func DeleteAlbum(c *gin.Context) {
 // @Tags artists
 // @Produce json
 // @Success 200 {array} models.Artist
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 500 {object} models.Problem
 // @Router /artists [get]
-func GetArtists(c *gin.Context) {
-	artists, err := db.GetArtists()
+func (h *Handler) GetArtists(c *gin.Context) {
+	artists, err := h.store.GetArtists(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve artists"})
+		respondInternalError(c, err, "Failed to retrieve artists")
 		return
 	}
 	c.JSON(http.StatusOK, artists)
 }
 
+// GetArtistByID handles GET /artists/:id request
+// @Summary Get artist by ID
+// @Description Retrieve a specific artist by its ID
+// @Tags artists
+// @Produce json
+// @Param id path string true "Artist ID"
+// @Success 200 {object} models.Artist
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /artists/{id} [get]
+func (h *Handler) GetArtistByID(c *gin.Context) {
+	id := c.Param("id")
+
+	artist, err := h.store.GetArtistByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve artist")
+		return
+	}
+
+	if artist == nil {
+		respondNotFound(c, "artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, artist)
+}
+
+// CreateArtist handles POST /artists request
+// @Summary Create a new artist
+// @Description Add a new artist to the collection
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param artist body models.Artist true "Artist Data"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /artists [post]
+func (h *Handler) CreateArtist(c *gin.Context) {
+	var artist models.Artist
+	if err := c.ShouldBindJSON(&artist); err != nil {
+		respondBindError(c, err, "Invalid artist data")
+		return
+	}
+
+	// Generate a UUID if not provided
+	if artist.ID == "" {
+		artist.ID = "art-" + uuid.New().String()[:8]
+	}
+
+	if err := h.store.CreateArtist(c.Request.Context(), artist); err != nil {
+		respondDBError(c, err, "artist", "Failed to create artist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": artist.ID})
+}
+
+// UpdateArtist handles PUT /artists/:id request
+// @Summary Update an artist
+// @Description Replace an existing artist's information
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path string true "Artist ID"
+// @Param artist body models.Artist true "Artist Data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /artists/{id} [put]
+func (h *Handler) UpdateArtist(c *gin.Context) {
+	id := c.Param("id")
+
+	var artist models.Artist
+	if err := c.ShouldBindJSON(&artist); err != nil {
+		respondBindError(c, err, "Invalid artist data")
+		return
+	}
+
+	// Ensure the ID in the path matches the ID in the body
+	artist.ID = id
+
+	if err := h.store.UpdateArtist(c.Request.Context(), artist); err != nil {
+		respondDBError(c, err, "artist", "Failed to update artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Artist updated successfully"})
+}
+
+// PatchArtist handles PATCH /artists/:id request
+// @Summary Partially update an artist
+// @Description Change only the supplied fields of an existing artist
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path string true "Artist ID"
+// @Param artist body models.ArtistPatch true "Fields to change"
+// @Success 200 {object} models.Artist
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /artists/{id} [patch]
+func (h *Handler) PatchArtist(c *gin.Context) {
+	id := c.Param("id")
+
+	var patch models.ArtistPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondBindError(c, err, "Invalid artist data")
+		return
+	}
+
+	// The store merges the patch in a single update, so concurrent patches of
+	// different fields don't overwrite each other
+	if err := h.store.PatchArtist(c.Request.Context(), id, patch); err != nil {
+		respondDBError(c, err, "artist", "Failed to update artist")
+		return
+	}
+
+	artist, err := h.store.GetArtistByID(c.Request.Context(), id)
+	if err == nil && artist == nil {
+		err = fmt.Errorf("artist %s disappeared after it was patched", id)
+	}
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, artist)
+}
+
+// DeleteArtist handles DELETE /artists/:id request
+// @Summary Delete an artist
+// @Description Remove an artist from the collection. Artists that still have albums can't be deleted;
+// @Description delete their albums or move them to another artist first.
+// @Tags artists
+// @Produce json
+// @Param id path string true "Artist ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /artists/{id} [delete]
+func (h *Handler) DeleteArtist(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.DeleteArtist(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			respondNotFound(c, "artist")
+		case errors.Is(err, db.ErrConflict):
+			respondProblem(c, http.StatusConflict, codeHasAlbums, "Artist still has albums",
+				"Delete its albums or move them to another artist first")
+		default:
+			respondInternalError(c, err, "Failed to delete artist")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Artist deleted successfully"})
+}
+
 // GetGenres handles GET /genres request
 // @Summary Get all genres
 // @Description Retrieve all music genres in the collection
 // @Tags genres
 // @Produce json
 // @Success 200 {array} models.Genre
-// @Failure 500 {object} models.ErrorResponse
+// @Failure 500 {object} models.Problem
 // @Router /genres [get]
-func GetGenres(c *gin.Context) {
-	genres, err := db.GetGenres()
+func (h *Handler) GetGenres(c *gin.Context) {
+	genres, err := h.store.GetGenres(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to retrieve genres"})
+		respondInternalError(c, err, "Failed to retrieve genres")
 		return
 	}
 	c.JSON(http.StatusOK, genres)
 }
+
+// GetGenreByID handles GET /genres/:id request
+// @Summary Get genre by ID
+// @Description Retrieve a specific genre by its ID
+// @Tags genres
+// @Produce json
+// @Param id path string true "Genre ID"
+// @Success 200 {object} models.Genre
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres/{id} [get]
+func (h *Handler) GetGenreByID(c *gin.Context) {
+	id := c.Param("id")
+
+	genre, err := h.store.GetGenreByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve genre")
+		return
+	}
+
+	if genre == nil {
+		respondNotFound(c, "genre")
+		return
+	}
+
+	c.JSON(http.StatusOK, genre)
+}
+
+// GetGenreAlbums handles GET /genres/:id/albums request
+// @Summary Get albums in a genre
+// @Description Retrieve a page of albums in a genre. Accepts the same paging, sorting and filtering parameters as GET /albums.
+// @Tags genres
+// @Produce json
+// @Param id path string true "Genre ID"
+// @Param limit query int false "Page size (1-200)" default(50)
+// @Param offset query int false "Number of albums to skip" default(0)
+// @Param sort query string false "Sort field" Enums(title, artist, release_year, rating, condition) default(title)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param query query string false "Album query, e.g. year:1970..1979 rating:>=4"
+// @Success 200 {object} models.AlbumPage
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres/{id}/albums [get]
+func (h *Handler) GetGenreAlbums(c *gin.Context) {
+	id := c.Param("id")
+
+	filter, err := parseAlbumFilter(c)
+	if err != nil {
+		respondParameterError(c, err)
+		return
+	}
+	filter.GenreID = id
+
+	genre, err := h.store.GetGenreByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve albums")
+		return
+	}
+	if genre == nil {
+		respondNotFound(c, "genre")
+		return
+	}
+
+	page, err := h.store.GetAlbums(c.Request.Context(), filter)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve albums")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateGenre handles POST /genres request
+// @Summary Create a new genre
+// @Description Add a new genre, optionally with an emoji icon
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param genre body models.Genre true "Genre Data"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres [post]
+func (h *Handler) CreateGenre(c *gin.Context) {
+	var genre models.Genre
+	if err := c.ShouldBindJSON(&genre); err != nil {
+		respondBindError(c, err, "Invalid genre data")
+		return
+	}
+
+	// Generate a UUID if not provided
+	if genre.ID == "" {
+		genre.ID = "gen-" + uuid.New().String()[:8]
+	}
+
+	if err := h.store.CreateGenre(c.Request.Context(), genre); err != nil {
+		respondDBError(c, err, "genre", "Failed to create genre")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": genre.ID})
+}
+
+// UpdateGenre handles PUT /genres/:id request
+// @Summary Update a genre
+// @Description Replace an existing genre's name and icon
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path string true "Genre ID"
+// @Param genre body models.Genre true "Genre Data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres/{id} [put]
+func (h *Handler) UpdateGenre(c *gin.Context) {
+	id := c.Param("id")
+
+	var genre models.Genre
+	if err := c.ShouldBindJSON(&genre); err != nil {
+		respondBindError(c, err, "Invalid genre data")
+		return
+	}
+
+	// Ensure the ID in the path matches the ID in the body
+	genre.ID = id
+
+	if err := h.store.UpdateGenre(c.Request.Context(), genre); err != nil {
+		respondDBError(c, err, "genre", "Failed to update genre")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Genre updated successfully"})
+}
+
+// PatchGenre handles PATCH /genres/:id request
+// @Summary Partially update a genre
+// @Description Change only the supplied fields of a genre, e.g. just its icon
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Param id path string true "Genre ID"
+// @Param genre body models.GenrePatch true "Fields to change"
+// @Success 200 {object} models.Genre
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres/{id} [patch]
+func (h *Handler) PatchGenre(c *gin.Context) {
+	id := c.Param("id")
+
+	var patch models.GenrePatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondBindError(c, err, "Invalid genre data")
+		return
+	}
+
+	// The store merges the patch in a single update, so concurrent patches of
+	// different fields don't overwrite each other
+	if err := h.store.PatchGenre(c.Request.Context(), id, patch); err != nil {
+		respondDBError(c, err, "genre", "Failed to update genre")
+		return
+	}
+
+	genre, err := h.store.GetGenreByID(c.Request.Context(), id)
+	if err == nil && genre == nil {
+		err = fmt.Errorf("genre %s disappeared after it was patched", id)
+	}
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve genre")
+		return
+	}
+
+	c.JSON(http.StatusOK, genre)
+}
+
+// DeleteGenre handles DELETE /genres/:id request
+// @Summary Delete a genre
+// @Description Remove a genre. Genres that still have albums can't be deleted;
+// @Description delete their albums or move them to another genre first.
+// @Tags genres
+// @Produce json
+// @Param id path string true "Genre ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /genres/{id} [delete]
+func (h *Handler) DeleteGenre(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.DeleteGenre(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			respondNotFound(c, "genre")
+		case errors.Is(err, db.ErrConflict):
+			respondProblem(c, http.StatusConflict, codeHasAlbums, "Genre still has albums",
+				"Delete its albums or move them to another genre first")
+		default:
+			respondInternalError(c, err, "Failed to delete genre")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Genre deleted successfully"})
+}
+
+// GetCollections handles GET /collections request
+// @Summary Get all collections
+// @Description Retrieve every smart and manual collection. Manual collections list their album IDs in order.
+// @Tags collections
+// @Produce json
+// @Success 200 {array} models.Collection
+// @Failure 500 {object} models.Problem
+// @Router /collections [get]
+func (h *Handler) GetCollections(c *gin.Context) {
+	collections, err := h.store.GetCollections(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve collections")
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollectionByID handles GET /collections/:id request
+// @Summary Get collection by ID
+// @Description Retrieve a specific collection by its ID
+// @Tags collections
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Success 200 {object} models.Collection
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections/{id} [get]
+func (h *Handler) GetCollectionByID(c *gin.Context) {
+	id := c.Param("id")
+
+	collection, err := h.store.GetCollectionByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve collection")
+		return
+	}
+
+	if collection == nil {
+		respondNotFound(c, "collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// GetCollectionAlbums handles GET /collections/:id/albums request
+// @Summary Get albums in a collection
+// @Description Evaluate a collection and retrieve a page of its albums. A smart collection returns the albums
+// @Description currently matching its query; a manual collection returns its albums in the chosen order.
+// @Description The collection's sort applies unless the request sets one. Accepts the same paging, sorting
+// @Description and filtering parameters as GET /albums, which narrow the collection down further.
+// @Tags collections
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Param limit query int false "Page size (1-200)" default(50)
+// @Param offset query int false "Number of albums to skip" default(0)
+// @Param sort query string false "Sort field" Enums(title, artist, release_year, rating, condition)
+// @Param order query string false "Sort direction; without a sort, desc reverses the order of a manual collection" Enums(asc, desc)
+// @Param query query string false "Album query, e.g. year:1970..1979 rating:>=4"
+// @Success 200 {object} models.AlbumPage
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections/{id}/albums [get]
+func (h *Handler) GetCollectionAlbums(c *gin.Context) {
+	id := c.Param("id")
+
+	filter, err := parseAlbumFilter(c)
+	if err != nil {
+		respondParameterError(c, err)
+		return
+	}
+
+	collection, err := h.store.GetCollectionByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve albums")
+		return
+	}
+	if collection == nil {
+		respondNotFound(c, "collection")
+		return
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = collection.Sort
+		if filter.Order == "" {
+			filter.Order = collection.Order
+		}
+	}
+
+	var page *models.AlbumPage
+	if collection.Kind == models.CollectionSmart {
+		// The query was validated when the collection was saved
+		query, err := db.ParseAlbumQuery(collection.Query)
+		if err != nil {
+			respondInternalError(c, fmt.Errorf("collection %s: %w", id, err), "Failed to retrieve albums")
+			return
+		}
+		filter.Query = query.And(filter.Query)
+		page, err = h.store.GetAlbums(c.Request.Context(), filter)
+	} else {
+		page, err = h.store.GetCollectionAlbums(c.Request.Context(), id, filter)
+	}
+	if err != nil {
+		respondInternalError(c, err, "Failed to retrieve albums")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// CreateCollection handles POST /collections request
+// @Summary Create a new collection
+// @Description Save a smart collection with an album query, or a manual collection with a list of album IDs in order
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param collection body models.Collection true "Collection Data"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections [post]
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		respondBindError(c, err, "Invalid collection data")
+		return
+	}
+
+	// Generate a UUID if not provided
+	if collection.ID == "" {
+		collection.ID = "col-" + uuid.New().String()[:8]
+	}
+
+	if err := h.store.CreateCollection(c.Request.Context(), collection); err != nil {
+		respondDBError(c, err, "collection", "Failed to create collection")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": collection.ID})
+}
+
+// UpdateCollection handles PUT /collections/:id request
+// @Summary Update a collection
+// @Description Replace an existing collection, including its query or albums. The kind may change too.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Param collection body models.Collection true "Collection Data"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections/{id} [put]
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	id := c.Param("id")
+
+	var collection models.Collection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		respondBindError(c, err, "Invalid collection data")
+		return
+	}
+
+	// Ensure the ID in the path matches the ID in the body
+	collection.ID = id
+
+	if err := h.store.UpdateCollection(c.Request.Context(), collection); err != nil {
+		respondDBError(c, err, "collection", "Failed to update collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection updated successfully"})
+}
+
+// PatchCollection handles PATCH /collections/:id request
+// @Summary Partially update a collection
+// @Description Change only the supplied fields of a collection, e.g. rename it or reorder its albums.
+// @Description album_ids replaces the albums of a manual collection; query only applies to smart collections.
+// @Tags collections
+// @Accept json
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Param collection body models.CollectionPatch true "Fields to change"
+// @Success 200 {object} models.Collection
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections/{id} [patch]
+func (h *Handler) PatchCollection(c *gin.Context) {
+	id := c.Param("id")
+
+	var patch models.CollectionPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondBindError(c, err, "Invalid collection data")
+		return
+	}
+
+	collection, err := h.store.GetCollectionByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err, "Failed to update collection")
+		return
+	}
+	if collection == nil {
+		respondNotFound(c, "collection")
+		return
+	}
+
+	if patch.Name != nil {
+		collection.Name = *patch.Name
+	}
+	if patch.Query != nil {
+		collection.Query = *patch.Query
+	}
+	if patch.AlbumIDs != nil {
+		collection.AlbumIDs = *patch.AlbumIDs
+	}
+	if patch.Sort != nil {
+		collection.Sort = *patch.Sort
+	}
+	if patch.Order != nil {
+		collection.Order = *patch.Order
+	}
+
+	// Check the result as a whole, e.g. that a query isn't added to a manual collection
+	if err := binding.Validator.ValidateStruct(collection); err != nil {
+		respondBindError(c, err, "Invalid collection data")
+		return
+	}
+
+	if err := h.store.UpdateCollection(c.Request.Context(), *collection); err != nil {
+		respondDBError(c, err, "collection", "Failed to update collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection handles DELETE /collections/:id request
+// @Summary Delete a collection
+// @Description Remove a collection. Its albums stay in the vault.
+// @Tags collections
+// @Produce json
+// @Param id path string true "Collection ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /collections/{id} [delete]
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.store.DeleteCollection(c.Request.Context(), id); err != nil {
+		respondDBError(c, err, "collection", "Failed to delete collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+// Search handles GET /search request
+// @Summary Search the collection
+// @Description Full-text search over album titles, artist names, genre names and album notes, tolerating typos. Results of all types are mixed and ranked best match first, with the matching words of the snippet wrapped in <mark>.
+// @Tags search
+// @Produce json
+// @Param q query string true "Search text; words are ANDed, \"quoted phrases\" are kept together and -word excludes a word"
+// @Param type query string false "Comma-separated list of result types (album, artist, genre)"
+// @Param limit query int false "Page size (1-100)" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} models.SearchPage
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /search [get]
+func (h *Handler) Search(c *gin.Context) {
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		respondParameterError(c, err)
+		return
+	}
+
+	page, err := h.store.Search(c.Request.Context(), query)
+	if err != nil {
+		respondInternalError(c, err, "Failed to search")
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
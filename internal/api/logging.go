@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID that ties a request to its log lines
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits incoming request IDs to what is safe to echo and log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+type loggerKey struct{}
+type requestIDKey struct{}
+
+// RequestID takes the request ID from the X-Request-ID header, or makes one up if the
+// header is missing or malformed, and echoes it in the response. The request's context
+// carries the ID and a logger that adds it to every line, along with the trace ID
+// when the request is traced.
+func RequestID(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, id)
+
+		requestLogger := logger.With("request_id", id)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+		}
+
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, id)
+		ctx = context.WithValue(ctx, loggerKey{}, requestLogger)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID RequestID assigned to the request, if any
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// LoggerFromContext returns the request's logger, or the default logger outside a request
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// AccessLog logs one line per request once it has been served. Server errors are logged
+// at the error level and client errors at the warn level.
+// The route is the registered pattern, e.g. /albums/:id, so lines for one endpoint group together.
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		ctx := c.Request.Context()
+		LoggerFromContext(ctx).LogAttrs(ctx, level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// Recovery turns panics into a 500 problem response and logs them with the request ID
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		ctx := c.Request.Context()
+		LoggerFromContext(ctx).ErrorContext(ctx, "panic while serving request", "panic", recovered)
+		respondProblem(c, http.StatusInternalServerError, codeInternalError, "Internal server error", "")
+		c.Abort()
+	})
+}
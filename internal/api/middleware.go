@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// QueryTimeout gives every request's context a deadline, so store queries started by a
+// slow request are cancelled once it passes. A timeout of 0 disables the limit.
+func QueryTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// CORS applies the configured cross-origin policy. Register it before the routes so that
+// it answers preflight requests, which the routes don't handle themselves.
+// Origins may be patterns such as https://*.example.com, and "*" allows any origin.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	corsConfig := cors.Config{
+		AllowOrigins:     cfg.AllowedOrigins,
+		AllowMethods:     cfg.AllowedMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+		AllowWildcard:    true,
+	}
+	if slices.Contains(cfg.AllowedOrigins, "*") {
+		corsConfig.AllowOrigins = nil
+		corsConfig.AllowAllOrigins = true
+	}
+	return cors.New(corsConfig)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// parseAlbumFilter reads the pagination, sorting and filtering query parameters of GET /albums
+func parseAlbumFilter(c *gin.Context) (db.AlbumFilter, error) {
+	filter := db.AlbumFilter{
+		Sort:     c.Query("sort"),
+		Order:    strings.ToLower(c.Query("order")),
+		ArtistID: c.Query("artist_id"),
+		GenreID:  c.Query("genre_id"),
+	}
+
+	if condition := c.Query("condition"); condition != "" {
+		for _, value := range strings.Split(condition, ",") {
+			filter.Conditions = append(filter.Conditions, models.AlbumCondition(strings.TrimSpace(value)))
+		}
+	}
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+		{"min_rating", &filter.MinRating},
+		{"max_rating", &filter.MaxRating},
+		{"min_year", &filter.MinYear},
+		{"max_year", &filter.MaxYear},
+	}
+	for _, param := range ints {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an integer", param.name)
+		}
+		*param.value = value
+	}
+
+	if raw := c.Query("query"); raw != "" {
+		query, err := db.ParseAlbumQuery(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.Query = query
+	}
+
+	return filter, filter.Validate()
+}
+
+// parseSearchQuery reads the search text, result types and pagination query parameters of GET /search
+func parseSearchQuery(c *gin.Context) (db.SearchQuery, error) {
+	query := db.SearchQuery{Text: c.Query("q")}
+
+	if types := c.Query("type"); types != "" {
+		for _, value := range strings.Split(types, ",") {
+			query.Types = append(query.Types, strings.ToLower(strings.TrimSpace(value)))
+		}
+	}
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"limit", &query.Limit},
+		{"offset", &query.Offset},
+	}
+	for _, param := range ints {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an integer", param.name)
+		}
+		*param.value = value
+	}
+
+	return query, query.Validate()
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchableAlbumFields lists the album members a client may change with PATCH.
+// id, artist and genre are read-only; artist_id and genre_id change the joined records.
+var patchableAlbumFields = []string{"title", "artist_id", "release_year", "genre_id", "notes", "rating", "condition"}
+
+// errPatchTestFailed is returned when a JSON Patch "test" operation doesn't match the current album
+var errPatchTestFailed = errors.New("test operation failed")
+
+// mergePatchAlbum turns an RFC 7396 merge patch document into an AlbumPatch.
+// A null member removes the value, which is only allowed for notes.
+func mergePatchAlbum(body []byte) (models.AlbumPatch, error) {
+	var patch models.AlbumPatch
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return patch, fmt.Errorf("patch must be a JSON object")
+	}
+
+	for name, value := range members {
+		if !isPatchableAlbumField(name) {
+			return patch, fmt.Errorf("%s can't be changed", name)
+		}
+		if string(value) != "null" {
+			continue
+		}
+		if name != "notes" {
+			return patch, fmt.Errorf("%s can't be removed", name)
+		}
+		// Removing the notes clears them
+		members[name] = json.RawMessage(`""`)
+	}
+
+	normalized, err := json.Marshal(members)
+	if err != nil {
+		return patch, err
+	}
+	if err := json.Unmarshal(normalized, &patch); err != nil {
+		return patch, fmt.Errorf("patch contains a value of the wrong type")
+	}
+
+	return patch, nil
+}
+
+// jsonPatchOperation is a single RFC 6902 operation
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatchAlbum applies an RFC 6902 JSON Patch to the current album and returns
+// the resulting changes as an AlbumPatch. Albums are flat documents, so only
+// top-level paths such as "/rating" are supported.
+//
+// The patch is applied to the album as read, so it also returns the current values
+// the result depends on, the members tested or copied before they were changed.
+// The store only writes the changes while the album still has them.
+func jsonPatchAlbum(current *models.Album, body []byte) (patch, expected models.AlbumPatch, err error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return patch, expected, fmt.Errorf("patch must be a JSON array of operations")
+	}
+
+	original, err := patchableAlbumDocument(current)
+	if err != nil {
+		return patch, expected, err
+	}
+	document, err := patchableAlbumDocument(current)
+	if err != nil {
+		return patch, expected, err
+	}
+
+	// Members read before any operation wrote them hold the current values
+	read := map[string]interface{}{}
+	written := map[string]bool{}
+	for i, operation := range operations {
+		for _, name := range jsonPatchReads(operation) {
+			if value, exists := original[name]; exists && !written[name] {
+				read[name] = value
+			}
+		}
+		if err := applyJSONPatchOperation(document, operation); err != nil {
+			return patch, expected, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+		for _, name := range jsonPatchWrites(operation) {
+			written[name] = true
+		}
+	}
+
+	// Express the result as a merge patch of the members that actually changed
+	changes := map[string]interface{}{}
+	for _, name := range patchableAlbumFields {
+		value, exists := document[name]
+		switch {
+		case !exists:
+			changes[name] = nil
+		case !reflect.DeepEqual(value, original[name]):
+			changes[name] = value
+		}
+	}
+
+	merge, err := json.Marshal(changes)
+	if err != nil {
+		return patch, expected, err
+	}
+	if patch, err = mergePatchAlbum(merge); err != nil {
+		return patch, expected, err
+	}
+
+	merge, err = json.Marshal(read)
+	if err != nil {
+		return patch, expected, err
+	}
+	expected, err = mergePatchAlbum(merge)
+	return patch, expected, err
+}
+
+// jsonPatchReads returns the members whose value an operation depends on
+func jsonPatchReads(operation jsonPatchOperation) []string {
+	var pointer string
+	switch operation.Op {
+	case "test":
+		pointer = operation.Path
+	case "move", "copy":
+		pointer = operation.From
+	default:
+		return nil
+	}
+	name, err := jsonPointerMember(pointer)
+	if err != nil {
+		return nil
+	}
+	return []string{name}
+}
+
+// jsonPatchWrites returns the members an operation changes
+func jsonPatchWrites(operation jsonPatchOperation) []string {
+	var names []string
+	switch operation.Op {
+	case "add", "replace", "remove", "copy":
+		names = append(names, operation.Path)
+	case "move":
+		names = append(names, operation.Path, operation.From)
+	}
+
+	var members []string
+	for _, pointer := range names {
+		if name, err := jsonPointerMember(pointer); err == nil {
+			members = append(members, name)
+		}
+	}
+	return members
+}
+
+// applyJSONPatchOperation applies a single operation to a flat JSON document
+func applyJSONPatchOperation(document map[string]interface{}, operation jsonPatchOperation) error {
+	name, err := jsonPointerMember(operation.Path)
+	if err != nil {
+		return err
+	}
+
+	switch operation.Op {
+	case "add", "replace":
+		value, err := decodePatchValue(operation.Value)
+		if err != nil {
+			return err
+		}
+		if _, exists := document[name]; !exists && operation.Op == "replace" {
+			return fmt.Errorf("path does not exist")
+		}
+		document[name] = value
+	case "remove":
+		if _, exists := document[name]; !exists {
+			return fmt.Errorf("path does not exist")
+		}
+		delete(document, name)
+	case "move", "copy":
+		from, err := jsonPointerMember(operation.From)
+		if err != nil {
+			return err
+		}
+		value, exists := document[from]
+		if !exists {
+			return fmt.Errorf("from path does not exist")
+		}
+		if operation.Op == "move" {
+			delete(document, from)
+		}
+		document[name] = value
+	case "test":
+		value, err := decodePatchValue(operation.Value)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(document[name], value) {
+			return errPatchTestFailed
+		}
+	default:
+		return fmt.Errorf("unsupported operation")
+	}
+
+	return nil
+}
+
+// patchableAlbumDocument returns the patchable members of an album as a generic JSON document
+func patchableAlbumDocument(album *models.Album) (map[string]interface{}, error) {
+	raw, err := json.Marshal(album)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	for name := range document {
+		if !isPatchableAlbumField(name) {
+			delete(document, name)
+		}
+	}
+	return document, nil
+}
+
+// jsonPointerMember resolves an RFC 6901 pointer to a top-level album member
+func jsonPointerMember(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", fmt.Errorf("only top-level paths are supported")
+	}
+
+	name := strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:])
+	if !isPatchableAlbumField(name) {
+		return "", fmt.Errorf("%s can't be changed", name)
+	}
+	return name, nil
+}
+
+// decodePatchValue decodes the value of an operation the same way the album document was decoded
+func decodePatchValue(raw json.RawMessage) (interface{}, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON")
+	}
+	return value, nil
+}
+
+func isPatchableAlbumField(name string) bool {
+	for _, field := range patchableAlbumFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,37 +1,66 @@
 package api
 
 import (
-	"github.com/gin-contrib/cors"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/health"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// RegisterRoutes sets up the API routes
-func RegisterRoutes(router *gin.Engine) {
-	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowAllOrigins: true,
-		AllowMethods:    []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:    []string{"Origin", "Content-Type", "Accept"},
-	}))
+// RegisterRoutes sets up the API routes, serving them from the given store.
+// The readiness endpoint runs the store's checks followed by any extra checks.
+func RegisterRoutes(router *gin.Engine, store db.Store, checks ...health.Check) {
+	h := NewHandler(store)
+	readiness := health.NewChecker(health.DefaultTimeout, append(health.StoreChecks(store), checks...)...)
 
-	// Health check
+	// Unknown routes and methods get problem responses too
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NoRoute)
+	router.NoMethod(NoMethod)
+
+	// Health checks
 	router.GET("/", HealthCheck)
+	router.GET("/healthz", HealthCheck)
+	router.GET("/readyz", Readiness(readiness))
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Albums routes
-	router.GET("/albums", GetAlbums)
-	router.GET("/albums/:id", GetAlbumByID)
-	router.POST("/albums", CreateAlbum)
-	router.PUT("/albums/:id", UpdateAlbum)
-	router.DELETE("/albums/:id", DeleteAlbum)
+	router.GET("/albums", h.GetAlbums)
+	router.GET("/albums/:id", h.GetAlbumByID)
+	router.POST("/albums", h.CreateAlbum)
+	router.PUT("/albums/:id", h.UpdateAlbum)
+	router.PATCH("/albums/:id", h.PatchAlbum)
+	router.DELETE("/albums/:id", h.DeleteAlbum)
+
+	// Artists routes
+	router.GET("/artists", h.GetArtists)
+	router.GET("/artists/:id", h.GetArtistByID)
+	router.POST("/artists", h.CreateArtist)
+	router.PUT("/artists/:id", h.UpdateArtist)
+	router.PATCH("/artists/:id", h.PatchArtist)
+	router.DELETE("/artists/:id", h.DeleteArtist)
+
+	// Genres routes
+	router.GET("/genres", h.GetGenres)
+	router.GET("/genres/:id", h.GetGenreByID)
+	router.GET("/genres/:id/albums", h.GetGenreAlbums)
+	router.POST("/genres", h.CreateGenre)
+	router.PUT("/genres/:id", h.UpdateGenre)
+	router.PATCH("/genres/:id", h.PatchGenre)
+	router.DELETE("/genres/:id", h.DeleteGenre)
 
-	// Artists route
-	router.GET("/artists", GetArtists)
+	// Collections routes
+	router.GET("/collections", h.GetCollections)
+	router.GET("/collections/:id", h.GetCollectionByID)
+	router.GET("/collections/:id/albums", h.GetCollectionAlbums)
+	router.POST("/collections", h.CreateCollection)
+	router.PUT("/collections/:id", h.UpdateCollection)
+	router.PATCH("/collections/:id", h.PatchCollection)
+	router.DELETE("/collections/:id", h.DeleteCollection)
 
-	// Genres route
-	router.GET("/genres", GetGenres)
+	// Search
+	router.GET("/search", h.Search)
 }
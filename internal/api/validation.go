@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// firstReleaseYear is the year the long-playing record was introduced
+const firstReleaseYear = 1948
+
+// Register the custom validators on Gin's validator so that they apply to every
+// ShouldBindJSON call as well as to binding.Validator.ValidateStruct
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("api: unexpected validator engine")
+	}
+
+	// Report JSON field names instead of Go struct field names
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	must(validate.RegisterValidation("notblank", validateNotBlank))
+	must(validate.RegisterValidation("release_year", validateReleaseYear))
+	must(validate.RegisterValidation("album_condition", validateAlbumCondition))
+	must(validate.RegisterValidation("album_query", validateAlbumQuery))
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// validateNotBlank rejects strings that are empty once surrounding whitespace is trimmed
+func validateNotBlank(fl validator.FieldLevel) bool {
+	return strings.TrimSpace(fl.Field().String()) != ""
+}
+
+// validateReleaseYear accepts four-digit years from the first LP up to next year,
+// leaving room for announced pre-orders
+func validateReleaseYear(fl validator.FieldLevel) bool {
+	value := strings.TrimSpace(fl.Field().String())
+	if len(value) != 4 {
+		return false
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil {
+		return false
+	}
+	return year >= firstReleaseYear && year <= latestReleaseYear()
+}
+
+// validateAlbumCondition accepts only the conditions allowed by the albums table
+func validateAlbumCondition(fl validator.FieldLevel) bool {
+	return models.AlbumCondition(fl.Field().String()).IsValid()
+}
+
+// validateAlbumQuery accepts album queries that parse; an empty query is left to required rules
+func validateAlbumQuery(fl validator.FieldLevel) bool {
+	_, err := db.ParseAlbumQuery(fl.Field().String())
+	return err == nil
+}
+
+func latestReleaseYear() int {
+	return time.Now().Year() + 1
+}
+
+// respondBindError writes the response for a request body that couldn't be bound.
+// Validation failures list every invalid field with a 422, malformed bodies get a 400.
+func respondBindError(c *gin.Context, err error, title string) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		respondProblem(c, http.StatusBadRequest, codeInvalidBody, title, "The request body is not valid JSON for this resource")
+		return
+	}
+
+	fields := make([]models.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, models.FieldError{
+			Field:   fieldErr.Field(),
+			Message: validationMessage(fieldErr),
+		})
+	}
+
+	respondProblem(c, http.StatusUnprocessableEntity, codeValidationFailed, title,
+		fmt.Sprintf("%d field(s) are invalid", len(fields)), fields...)
+}
+
+// describeCondition turns the "Field value" parameter of a conditional rule into "field is value"
+func describeCondition(param string) string {
+	field, value, _ := strings.Cut(param, " ")
+	return strings.ToLower(field) + " is " + value
+}
+
+// validationMessage describes a failed validation rule in plain words
+func validationMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "min":
+		return "must be at least " + err.Param()
+	case "max":
+		return "must be at most " + err.Param()
+	case "release_year":
+		return fmt.Sprintf("must be a year between %d and %d", firstReleaseYear, latestReleaseYear())
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(err.Param(), " ", ", ")
+	case "required_if":
+		return "is required when " + describeCondition(err.Param())
+	case "excluded_if":
+		return "must be empty when " + describeCondition(err.Param())
+	case "unique":
+		return "must not contain duplicates"
+	case "album_query":
+		_, queryErr := db.ParseAlbumQuery(fmt.Sprint(err.Value()))
+		return "is not a valid album query: " + queryErr.Error()
+	case "album_condition":
+		conditions := make([]string, len(models.AlbumConditions))
+		for i, condition := range models.AlbumConditions {
+			conditions[i] = string(condition)
+		}
+		return "must be one of " + strings.Join(conditions, ", ")
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// collectionColumns are the columns of the collections table, in the order scanCollection reads them
+const collectionColumns = `id, name, kind, query, sort, sort_order`
+
+// scanCollection reads a single collection row selected with collectionColumns
+func scanCollection(row pgx.Row) (*models.Collection, error) {
+	var collection models.Collection
+	err := row.Scan(&collection.ID, &collection.Name, &collection.Kind, &collection.Query, &collection.Sort, &collection.Order)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// collectionAlbumsQuery selects the albums of a manual collection that match a filter
+type collectionAlbumsQuery struct {
+	filter AlbumFilter
+	count  string
+	page   string
+	// args are the arguments of count; page takes the limit and offset after them
+	args []interface{}
+}
+
+// newCollectionAlbumsQuery builds the queries of GetCollectionAlbums. Without a sort field
+// in the filter, albums come in the order of the collection, reversed for a descending order.
+func newCollectionAlbumsQuery(id string, filter AlbumFilter) collectionAlbumsQuery {
+	byPosition := filter.Sort == ""
+	filter = filter.withDefaults()
+
+	where, args := filter.whereClause()
+	args = append(args, id)
+	joins := albumJoins + fmt.Sprintf("\n\t\tJOIN collection_albums ca ON ca.album_id = a.id AND ca.collection_id = $%d", len(args))
+
+	order := filter.orderClause()
+	if byPosition {
+		order = "\n\t\tORDER BY ca.position, a.id"
+		if filter.Order == "desc" {
+			order = "\n\t\tORDER BY ca.position DESC, a.id DESC"
+		}
+	}
+
+	return collectionAlbumsQuery{
+		filter: filter,
+		count:  "SELECT COUNT(*)" + joins + where,
+		page: "SELECT " + albumColumns + joins + where + order +
+			fmt.Sprintf("\n\t\tLIMIT $%d OFFSET $%d", len(args)+1, len(args)+2),
+		args: args,
+	}
+}
+
+// pageArgs returns the arguments of the page query
+func (q collectionAlbumsQuery) pageArgs() []interface{} {
+	return append(slices.Clip(q.args), q.filter.Limit, q.filter.Offset)
+}
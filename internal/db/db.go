@@ -2,11 +2,14 @@ package db
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/emirhanalptekin/vinylvault/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,93 +17,183 @@ import (
 // DBPool is an interface for database operations, useful for mocking in tests
 type DBPool interface {
 	Acquire(ctx context.Context) (*pgxpool.Conn, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 	Close()
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Ping(ctx context.Context) error
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 }
 
-// Database connection pool
-var dbPool DBPool
+// PostgresStore is the Postgres implementation of Store
+type PostgresStore struct {
+	pool DBPool
+}
 
-// InitializeDB initializes the database connection pool
-func InitializeDB(connString string) {
-	var err error
-	pool, err := pgxpool.New(context.Background(), connString)
-	for attempts := 0; attempts < 5; attempts++ {
-		if err == nil {
-			SetDBPool(pool)
-			log.Println("Connected to the database successfully.")
-			return
-		}
-		log.Printf("Unable to connect to database, retrying in 3 seconds... (%d/5)\n", attempts+1)
-		time.Sleep(3 * time.Second)
+// NewPostgresStore creates a store backed by the given connection pool, or by a mock in tests
+func NewPostgresStore(pool DBPool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// Ping checks that a connection to the database can be used
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+// PoolStats reports the state of the connection pool. Mocked pools have no statistics.
+func (s *PostgresStore) PoolStats() (PoolStats, bool) {
+	pool, ok := s.pool.(*pgxpool.Pool)
+	if !ok {
+		return PoolStats{}, false
 	}
+	stat := pool.Stat()
+	return PoolStats{
+		MaxConns:      int(stat.MaxConns()),
+		TotalConns:    int(stat.TotalConns()),
+		IdleConns:     int(stat.IdleConns()),
+		AcquiredConns: int(stat.AcquiredConns()),
+		WaitCount:     stat.EmptyAcquireCount(),
+		WaitDuration:  stat.EmptyAcquireWaitTime(),
+	}, true
+}
 
-	log.Fatalf("Unable to connect to database: %v\n", err)
+// Close closes the connection pool, waiting for acquired connections to be released
+func (s *PostgresStore) Close() error {
+	s.pool.Close()
+	return nil
 }
 
-// Function to set the dbPool variable - mostly used for testing
-func SetDBPool(pool DBPool) {
-	dbPool = pool
+// Migrator returns the runner for the Postgres migrations
+func (s *PostgresStore) Migrator() (*Migrator, error) {
+	return newMigrator(postgresMigrationTarget{conn: s.pool, acquire: s.acquireMigrationConn}, postgresMigrations, "migrations/postgres")
 }
 
-// GetAlbums retrieves all albums from the database
-func GetAlbums() ([]models.Album, error) {
-	rows, err := dbPool.Query(context.Background(), `
-		SELECT a.id, a.title, a.artist_id, ar.name, a.release_year, a.genre_id, g.name, g.icon, a.notes, a.rating, a.condition 
-		FROM albums a
-		JOIN artists ar ON a.artist_id = ar.id
-		JOIN genres g ON a.genre_id = g.id
-	`)
+// acquireMigrationConn takes a connection out of the pool for the migration lock. A connection
+// that may still hold the lock is closed rather than handed out again.
+func (s *PostgresStore) acquireMigrationConn(ctx context.Context) (PostgresConn, func(bool), error) {
+	conn, err := s.pool.Acquire(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer rows.Close()
+	return conn, func(unlocked bool) {
+		if !unlocked {
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
 
-	var albums []models.Album
-	for rows.Next() {
-		var album models.Album
-		var artistName, genreName, genreIcon string
-
-		err = rows.Scan(
-			&album.ID,
-			&album.Title,
-			&album.ArtistID,
-			&artistName,
-			&album.ReleaseYear,
-			&album.GenreID,
-			&genreName,
-			&genreIcon,
-			&album.Notes,
-			&album.Rating,
-			&album.Condition,
-		)
-		if err != nil {
-			return nil, err
+// ConnectOptions controls how InitializeDB waits for the database to come up and
+// sizes the connection pool. Pool settings left at 0 keep the pgx defaults.
+type ConnectOptions struct {
+	// Attempts is the number of connection attempts before giving up, at least 1
+	Attempts int
+	// MaxWait caps the wait between attempts, which doubles after every failure
+	MaxWait time.Duration
+
+	MinConns        int
+	MaxConns        int
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+
+	// Tracers are told about every query the pool runs, e.g. to time or trace them
+	Tracers []pgx.QueryTracer
+}
+
+// apply copies the pool settings that were set onto config
+func (o ConnectOptions) apply(config *pgxpool.Config) {
+	if o.MinConns > 0 {
+		config.MinConns = int32(o.MinConns)
+	}
+	if o.MaxConns > 0 {
+		config.MaxConns = int32(o.MaxConns)
+	}
+	if o.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = o.MaxConnLifetime
+	}
+	if o.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = o.MaxConnIdleTime
+	}
+	switch len(o.Tracers) {
+	case 0:
+	case 1:
+		config.ConnConfig.Tracer = o.Tracers[0]
+	default:
+		config.ConnConfig.Tracer = multitracer.New(o.Tracers...)
+	}
+}
+
+const (
+	// firstConnectWait is the wait after the first failed connection attempt
+	firstConnectWait = 500 * time.Millisecond
+	// pingTimeout limits a single connection attempt
+	pingTimeout = 5 * time.Second
+)
+
+// InitializeDB creates the connection pool and makes sure the database answers.
+// Every attempt dials the database and pings it; failed attempts are retried with
+// exponential backoff, and the error of the last attempt is returned once they run out.
+func InitializeDB(ctx context.Context, connString string, opts ConnectOptions) (*pgxpool.Pool, error) {
+	// Parsing errors won't go away by retrying. NewWithConfig doesn't dial yet.
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	opts.apply(config)
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database pool settings: %w", err)
+	}
+
+	attempts := max(opts.Attempts, 1)
+	wait := firstConnectWait
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err = pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			slog.InfoContext(ctx, "Connected to the database", "attempt", attempt)
+			return pool, nil
+		}
+		if attempt >= attempts {
+			break
 		}
 
-		album.Artist = &models.Artist{ID: album.ArtistID, Name: artistName}
-		album.Genre = &models.Genre{ID: album.GenreID, Name: genreName, Icon: genreIcon}
+		if opts.MaxWait > 0 {
+			wait = min(wait, opts.MaxWait)
+		}
+		slog.WarnContext(ctx, "Unable to connect to database, retrying",
+			"attempt", attempt, "attempts", attempts, "error", err, "retry_in", wait.String())
 
-		albums = append(albums, album)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			pool.Close()
+			return nil, ctx.Err()
+		}
+		wait *= 2
 	}
 
-	return albums, nil
+	pool.Close()
+	return nil, fmt.Errorf("unable to connect to database after %d attempt(s): %w", attempts, err)
 }
 
-// GetAlbumByID retrieves a single album by ID
-func GetAlbumByID(id string) (*models.Album, error) {
-	var album models.Album
-	var artistName, genreName, genreIcon string
+// albumColumns lists the columns selected by every album query, including the joined artist and genre
+const albumColumns = `a.id, a.title, a.artist_id, ar.name, a.release_year, a.genre_id, g.name, g.icon, a.notes, a.rating, a.condition`
 
-	err := dbPool.QueryRow(context.Background(), `
-		SELECT a.id, a.title, a.artist_id, ar.name, a.release_year, a.genre_id, g.name, g.icon, a.notes, a.rating, a.condition 
+// albumJoins joins albums with their artist and genre
+const albumJoins = `
 		FROM albums a
 		JOIN artists ar ON a.artist_id = ar.id
-		JOIN genres g ON a.genre_id = g.id
-		WHERE a.id = $1
-	`, id).Scan(
+		JOIN genres g ON a.genre_id = g.id`
+
+// scanAlbum reads a single album row selected with albumColumns
+func scanAlbum(row pgx.Row) (*models.Album, error) {
+	var album models.Album
+	var artistName, genreName, genreIcon string
+
+	err := row.Scan(
 		&album.ID,
 		&album.Title,
 		&album.ArtistID,
@@ -113,11 +206,7 @@ func GetAlbumByID(id string) (*models.Album, error) {
 		&album.Rating,
 		&album.Condition,
 	)
-
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, nil // No album found
-		}
 		return nil, err
 	}
 
@@ -127,42 +216,209 @@ func GetAlbumByID(id string) (*models.Album, error) {
 	return &album, nil
 }
 
+// GetAlbums retrieves a page of albums matching the filter
+func (s *PostgresStore) GetAlbums(ctx context.Context, filter AlbumFilter) (*models.AlbumPage, error) {
+	filter = filter.withDefaults()
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	where, args := filter.whereClause()
+
+	var total int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*)"+albumJoins+where, args...).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := "SELECT " + albumColumns + albumJoins + where + filter.orderClause() +
+		fmt.Sprintf("\n\t\tLIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newAlbumPage(albums, total, filter), nil
+}
+
+// CountAlbums counts the albums in the database
+func (s *PostgresStore) CountAlbums(ctx context.Context) (int, error) {
+	var count int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM albums").Scan(&count)
+	return count, err
+}
+
+// GetAlbumByID retrieves a single album by ID
+func (s *PostgresStore) GetAlbumByID(ctx context.Context, id string) (*models.Album, error) {
+	album, err := scanAlbum(s.pool.QueryRow(ctx, "SELECT "+albumColumns+albumJoins+"\n\t\tWHERE a.id = $1", id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // No album found
+		}
+		return nil, err
+	}
+
+	return album, nil
+}
+
 // CreateAlbum adds a new album to the database
-func CreateAlbum(album models.Album) error {
-	_, err := dbPool.Exec(context.Background(), `
+func (s *PostgresStore) CreateAlbum(ctx context.Context, album models.Album) error {
+	_, err := s.pool.Exec(ctx, `
 		INSERT INTO albums (id, title, artist_id, release_year, genre_id, notes, rating, condition)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`, album.ID, album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition)
 
-	return err
+	return translateError(err)
 }
 
 // UpdateAlbum updates an existing album
-func UpdateAlbum(album models.Album) error {
-	_, err := dbPool.Exec(context.Background(), `
+func (s *PostgresStore) UpdateAlbum(ctx context.Context, album models.Album) error {
+	tag, err := s.pool.Exec(ctx, `
 		UPDATE albums 
 		SET title = $2, artist_id = $3, release_year = $4, genre_id = $5, notes = $6, rating = $7, condition = $8
 		WHERE id = $1
 	`, album.ID, album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// albumPatchValues lists the columns of the fields supplied in a patch, with their values
+func albumPatchValues(patch models.AlbumPatch) ([]string, []interface{}) {
+	var columns []string
+	var values []interface{}
+
+	add := func(column string, value interface{}) {
+		columns = append(columns, column)
+		values = append(values, value)
+	}
+
+	if patch.Title != nil {
+		add("title", *patch.Title)
+	}
+	if patch.ArtistID != nil {
+		add("artist_id", *patch.ArtistID)
+	}
+	if patch.ReleaseYear != nil {
+		add("release_year", *patch.ReleaseYear)
+	}
+	if patch.GenreID != nil {
+		add("genre_id", *patch.GenreID)
+	}
+	if patch.Notes != nil {
+		add("notes", *patch.Notes)
+	}
+	if patch.Rating != nil {
+		add("rating", *patch.Rating)
+	}
+	if patch.Condition != nil {
+		add("condition", *patch.Condition)
+	}
+
+	return columns, values
+}
+
+// patchAlbumStatement builds the UPDATE of a patch, matching only an album that still has the
+// expected values. It returns an empty statement for an empty patch.
+func patchAlbumStatement(id string, patch, expected models.AlbumPatch) (string, []interface{}) {
+	args := []interface{}{id}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var sets []string
+	columns, values := albumPatchValues(patch)
+	for i, column := range columns {
+		sets = append(sets, column+" = "+param(values[i]))
+	}
+	if len(sets) == 0 {
+		return "", nil
+	}
+
+	conditions := []string{"id = $1"}
+	columns, values = albumPatchValues(expected)
+	for i, column := range columns {
+		// Albums without notes are read as empty notes
+		if column == "notes" {
+			column = "COALESCE(notes, '')"
+		}
+		conditions = append(conditions, column+" = "+param(values[i]))
+	}
 
-	return err
+	return "UPDATE albums SET " + strings.Join(sets, ", ") + " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// PatchAlbum updates only the columns supplied in the patch, leaving the others untouched.
+// An empty patch is a no-op.
+func (s *PostgresStore) PatchAlbum(ctx context.Context, id string, patch, expected models.AlbumPatch) error {
+	statement, args := patchAlbumStatement(id, patch, expected)
+	if statement == "" {
+		return nil
+	}
+
+	tag, err := s.pool.Exec(ctx, statement, args...)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	if expected.IsEmpty() {
+		return ErrNotFound
+	}
+
+	// The album is either gone or no longer has the expected values
+	var exists bool
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM albums WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrModified
+	}
+	return ErrNotFound
 }
 
 // DeleteAlbum removes an album from the database
-func DeleteAlbum(id string) error {
-	_, err := dbPool.Exec(context.Background(), "DELETE FROM albums WHERE id = $1", id)
-	return err
+func (s *PostgresStore) DeleteAlbum(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM albums WHERE id = $1", id)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
 // GetArtists retrieves all artists from the database
-func GetArtists() ([]models.Artist, error) {
-	rows, err := dbPool.Query(context.Background(), "SELECT id, name FROM artists")
+func (s *PostgresStore) GetArtists(ctx context.Context) ([]models.Artist, error) {
+	rows, err := s.pool.Query(ctx, "SELECT id, name FROM artists ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var artists []models.Artist
+	artists := []models.Artist{}
 	for rows.Next() {
 		var artist models.Artist
 		err = rows.Scan(&artist.ID, &artist.Name)
@@ -175,15 +431,90 @@ func GetArtists() ([]models.Artist, error) {
 	return artists, nil
 }
 
+// CountArtists counts the artists in the database
+func (s *PostgresStore) CountArtists(ctx context.Context) (int, error) {
+	var count int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM artists").Scan(&count)
+	return count, err
+}
+
+// GetArtistByID retrieves a single artist by ID
+func (s *PostgresStore) GetArtistByID(ctx context.Context, id string) (*models.Artist, error) {
+	var artist models.Artist
+	err := s.pool.QueryRow(ctx, "SELECT id, name FROM artists WHERE id = $1", id).
+		Scan(&artist.ID, &artist.Name)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // No artist found
+		}
+		return nil, err
+	}
+
+	return &artist, nil
+}
+
+// CreateArtist adds a new artist to the database
+func (s *PostgresStore) CreateArtist(ctx context.Context, artist models.Artist) error {
+	_, err := s.pool.Exec(ctx, "INSERT INTO artists (id, name) VALUES ($1, $2)", artist.ID, artist.Name)
+	return translateError(err)
+}
+
+// UpdateArtist updates an existing artist
+func (s *PostgresStore) UpdateArtist(ctx context.Context, artist models.Artist) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE artists SET name = $2 WHERE id = $1", artist.ID, artist.Name)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PatchArtist updates only the fields supplied in the patch
+func (s *PostgresStore) PatchArtist(ctx context.Context, id string, patch models.ArtistPatch) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE artists SET name = COALESCE($2, name) WHERE id = $1", id, patch.Name)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteArtist removes an artist from the database.
+// Artists that still have albums are never deleted; ErrConflict is returned instead
+// so that their albums have to be removed or moved to another artist first.
+func (s *PostgresStore) DeleteArtist(ctx context.Context, id string) error {
+	var albums int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE artist_id = $1", id).Scan(&albums)
+	if err != nil {
+		return err
+	}
+	if albums > 0 {
+		return fmt.Errorf("%w: artist %s still has %d album(s)", ErrConflict, id, albums)
+	}
+
+	tag, err := s.pool.Exec(ctx, "DELETE FROM artists WHERE id = $1", id)
+	if err != nil {
+		return deleteConflict("artist", id, translateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // GetGenres retrieves all genres from the database
-func GetGenres() ([]models.Genre, error) {
-	rows, err := dbPool.Query(context.Background(), "SELECT id, name, icon FROM genres")
+func (s *PostgresStore) GetGenres(ctx context.Context) ([]models.Genre, error) {
+	rows, err := s.pool.Query(ctx, "SELECT id, name, icon FROM genres ORDER BY id")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var genres []models.Genre
+	genres := []models.Genre{}
 	for rows.Next() {
 		var genre models.Genre
 		err = rows.Scan(&genre.ID, &genre.Name, &genre.Icon)
@@ -195,3 +526,279 @@ func GetGenres() ([]models.Genre, error) {
 
 	return genres, nil
 }
+
+// CountGenres counts the genres in the database
+func (s *PostgresStore) CountGenres(ctx context.Context) (int, error) {
+	var count int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM genres").Scan(&count)
+	return count, err
+}
+
+// GetGenreByID retrieves a single genre by ID
+func (s *PostgresStore) GetGenreByID(ctx context.Context, id string) (*models.Genre, error) {
+	var genre models.Genre
+	err := s.pool.QueryRow(ctx, "SELECT id, name, icon FROM genres WHERE id = $1", id).
+		Scan(&genre.ID, &genre.Name, &genre.Icon)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // No genre found
+		}
+		return nil, err
+	}
+
+	return &genre, nil
+}
+
+// CreateGenre adds a new genre to the database
+func (s *PostgresStore) CreateGenre(ctx context.Context, genre models.Genre) error {
+	_, err := s.pool.Exec(ctx, "INSERT INTO genres (id, name, icon) VALUES ($1, $2, $3)", genre.ID, genre.Name, genre.Icon)
+	return translateError(err)
+}
+
+// UpdateGenre updates an existing genre, including its icon
+func (s *PostgresStore) UpdateGenre(ctx context.Context, genre models.Genre) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE genres SET name = $2, icon = $3 WHERE id = $1", genre.ID, genre.Name, genre.Icon)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PatchGenre updates only the fields supplied in the patch
+func (s *PostgresStore) PatchGenre(ctx context.Context, id string, patch models.GenrePatch) error {
+	tag, err := s.pool.Exec(ctx, "UPDATE genres SET name = COALESCE($2, name), icon = COALESCE($3, icon) WHERE id = $1", id, patch.Name, patch.Icon)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteGenre removes a genre from the database.
+// Like artists, genres that still have albums are never deleted and ErrConflict is returned instead.
+func (s *PostgresStore) DeleteGenre(ctx context.Context, id string) error {
+	var albums int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM albums WHERE genre_id = $1", id).Scan(&albums)
+	if err != nil {
+		return err
+	}
+	if albums > 0 {
+		return fmt.Errorf("%w: genre %s still has %d album(s)", ErrConflict, id, albums)
+	}
+
+	tag, err := s.pool.Exec(ctx, "DELETE FROM genres WHERE id = $1", id)
+	if err != nil {
+		return deleteConflict("genre", id, translateError(err))
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Search finds albums, artists and genres matching the query, best match first.
+// It uses the tsvector columns and trigram indexes added by the search migration.
+func (s *PostgresStore) Search(ctx context.Context, query SearchQuery) (*models.SearchPage, error) {
+	query = query.withDefaults()
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	var total int
+	err := s.pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+query.resultsClause(), query.Text).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, query.pageQuery(), query.Text, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Name, &result.Snippet, &result.Score); err != nil {
+			return nil, err
+		}
+		result.Href = searchHref(result.Type, result.ID)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newSearchPage(results, total, query), nil
+}
+
+// GetCollections retrieves all collections, ordered by name
+func (s *PostgresStore) GetCollections(ctx context.Context) ([]models.Collection, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+collectionColumns+" FROM collections ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Attach the albums of the manual collections
+	rows, err = s.pool.Query(ctx, "SELECT collection_id, album_id FROM collection_albums ORDER BY collection_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albumIDs := map[string][]string{}
+	for rows.Next() {
+		var collectionID, albumID string
+		if err := rows.Scan(&collectionID, &albumID); err != nil {
+			return nil, err
+		}
+		albumIDs[collectionID] = append(albumIDs[collectionID], albumID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range collections {
+		collections[i].AlbumIDs = albumIDs[collections[i].ID]
+	}
+	return collections, nil
+}
+
+// GetCollectionByID retrieves a single collection by ID, with the albums of a manual collection in order
+func (s *PostgresStore) GetCollectionByID(ctx context.Context, id string) (*models.Collection, error) {
+	collection, err := scanCollection(s.pool.QueryRow(ctx, "SELECT "+collectionColumns+" FROM collections WHERE id = $1", id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil // No collection found
+		}
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, "SELECT album_id FROM collection_albums WHERE collection_id = $1 ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var albumID string
+		if err := rows.Scan(&albumID); err != nil {
+			return nil, err
+		}
+		collection.AlbumIDs = append(collection.AlbumIDs, albumID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// CreateCollection adds a new collection and its albums in a single transaction
+func (s *PostgresStore) CreateCollection(ctx context.Context, collection models.Collection) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO collections (id, name, kind, query, sort, sort_order) VALUES ($1, $2, $3, $4, $5, $6)",
+			collection.ID, collection.Name, collection.Kind, collection.Query, collection.Sort, collection.Order)
+		if err != nil {
+			return translateError(err)
+		}
+		return insertCollectionAlbums(ctx, tx, collection)
+	})
+}
+
+// UpdateCollection replaces a collection and its albums in a single transaction
+func (s *PostgresStore) UpdateCollection(ctx context.Context, collection models.Collection) error {
+	return pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			"UPDATE collections SET name = $2, kind = $3, query = $4, sort = $5, sort_order = $6 WHERE id = $1",
+			collection.ID, collection.Name, collection.Kind, collection.Query, collection.Sort, collection.Order)
+		if err != nil {
+			return translateError(err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM collection_albums WHERE collection_id = $1", collection.ID); err != nil {
+			return err
+		}
+		return insertCollectionAlbums(ctx, tx, collection)
+	})
+}
+
+// insertCollectionAlbums stores the albums of a collection, numbered in order
+func insertCollectionAlbums(ctx context.Context, tx pgx.Tx, collection models.Collection) error {
+	if len(collection.AlbumIDs) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO collection_albums (collection_id, album_id, position)
+		SELECT $1, album_id, position FROM unnest($2::text[]) WITH ORDINALITY AS ids(album_id, position)
+	`, collection.ID, collection.AlbumIDs)
+	return translateError(err)
+}
+
+// DeleteCollection removes a collection; its albums are left alone
+func (s *PostgresStore) DeleteCollection(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM collections WHERE id = $1", id)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetCollectionAlbums retrieves a page of the albums of a manual collection matching the filter
+func (s *PostgresStore) GetCollectionAlbums(ctx context.Context, id string, filter AlbumFilter) (*models.AlbumPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query := newCollectionAlbumsQuery(id, filter)
+
+	var total int
+	if err := s.pool.QueryRow(ctx, query.count, query.args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, query.page, query.pageArgs()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newAlbumPage(albums, total, query.filter), nil
+}
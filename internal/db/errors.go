@@ -0,0 +1,152 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrNotFound is returned when the requested record doesn't exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a change would leave the collection in an inconsistent state,
+	// e.g. deleting an artist that still has albums or reusing an existing ID
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidReference is returned when a record points at an artist, genre or album that doesn't exist
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrInvalidValue is returned when a value is rejected by a CHECK or NOT NULL constraint
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrModified is returned when a record no longer has the values a change was based on
+	ErrModified = errors.New("record was modified")
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// constraintFields maps the constraint names generated by Postgres for our schema
+// to the JSON field they guard
+var constraintFields = map[string]string{
+	"albums_pkey":            "id",
+	"artists_pkey":           "id",
+	"genres_pkey":            "id",
+	"albums_artist_id_fkey":  "artist_id",
+	"albums_genre_id_fkey":   "genre_id",
+	"albums_rating_check":    "rating",
+	"albums_condition_check": "condition",
+
+	"collections_pkey":                     "id",
+	"collections_kind_check":               "kind",
+	"collection_albums_pkey":               "album_ids",
+	"collection_albums_album_id_fkey":      "album_ids",
+	"collection_albums_collection_id_fkey": "id",
+}
+
+// ConstraintError describes a violated database constraint in terms of the offending field.
+// It wraps one of ErrConflict, ErrInvalidReference or ErrInvalidValue, so callers can use errors.Is.
+type ConstraintError struct {
+	Kind       error
+	Field      string
+	Constraint string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s on %s: %v", e.Kind, e.Field, e.Err)
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// translateError turns Postgres constraint violations into a ConstraintError.
+// Any other error is returned unchanged.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		kind = ErrConflict
+	case pgForeignKeyViolation:
+		kind = ErrInvalidReference
+	case pgCheckViolation, pgNotNullViolation:
+		kind = ErrInvalidValue
+	default:
+		return err
+	}
+
+	field, ok := constraintFields[pgErr.ConstraintName]
+	if !ok {
+		field = pgErr.ColumnName
+	}
+
+	return &ConstraintError{Kind: kind, Field: field, Constraint: pgErr.ConstraintName, Err: err}
+}
+
+// violation builds the ConstraintError that Postgres would report for one of our constraints.
+// It lets stores that enforce constraints themselves report them the same way.
+func violation(kind error, constraint string) error {
+	return &ConstraintError{
+		Kind:       kind,
+		Field:      constraintFields[constraint],
+		Constraint: constraint,
+		Err:        fmt.Errorf("violates constraint %q", constraint),
+	}
+}
+
+// deleteConflict reports the error of deleting an artist or genre. Deletes count the record's
+// albums first, but an album may be added between the count and the delete; the foreign key then
+// rejects the delete, which is reported as the same ErrConflict as a non-zero count.
+func deleteConflict(resource, id string, err error) error {
+	if errors.Is(err, ErrInvalidReference) {
+		return fmt.Errorf("%w: %s %s still has albums", ErrConflict, resource, id)
+	}
+	return err
+}
+
+// translateSQLiteError turns SQLite constraint violations into a ConstraintError.
+// Any other error is returned unchanged.
+//
+// SQLite names CHECK constraints but reports unique and NOT NULL violations as "table.column",
+// and doesn't say which foreign key failed at all, so the field is left empty for those.
+func translateSQLiteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	// Messages look like "constraint failed: CHECK constraint failed: albums_rating_check (275)"
+	detail := sqliteErr.Error()
+	if i := strings.LastIndex(detail, "failed: "); i >= 0 {
+		detail, _, _ = strings.Cut(detail[i+len("failed: "):], " ")
+	}
+	table, column, _ := strings.Cut(detail, ".")
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		if column == "id" {
+			return &ConstraintError{Kind: ErrConflict, Field: "id", Constraint: table + "_pkey", Err: err}
+		}
+		return &ConstraintError{Kind: ErrConflict, Field: column, Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return &ConstraintError{Kind: ErrInvalidReference, Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return &ConstraintError{Kind: ErrInvalidValue, Field: constraintFields[detail], Constraint: detail, Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return &ConstraintError{Kind: ErrInvalidValue, Field: column, Err: err}
+	default:
+		return err
+	}
+}
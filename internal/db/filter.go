@@ -0,0 +1,224 @@
+package db
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+const (
+	// DefaultAlbumLimit is the page size used when the client doesn't ask for one
+	DefaultAlbumLimit = 50
+	// MaxAlbumLimit caps the page size a client can request
+	MaxAlbumLimit = 200
+)
+
+// albumSortColumns maps the public sort fields to their SQL expressions
+var albumSortColumns = map[string]string{
+	"title":        "a.title",
+	"artist":       "ar.name",
+	"release_year": "a.release_year",
+	"rating":       "a.rating",
+	// Conditions are ranked from best (Mint) to worst (Poor) rather than alphabetically
+	"condition": `CASE a.condition WHEN 'Mint' THEN 1 WHEN 'Excellent' THEN 2 WHEN 'Very Good' THEN 3 WHEN 'Good' THEN 4 WHEN 'Fair' THEN 5 WHEN 'Poor' THEN 6 END`,
+}
+
+// AlbumFilter describes which page of albums to return and how to filter and sort them.
+// Zero values mean "no filter"; Limit and Sort fall back to sensible defaults.
+type AlbumFilter struct {
+	Limit  int
+	Offset int
+	Sort   string // title, artist, release_year, rating or condition
+	Order  string // asc or desc
+
+	ArtistID   string
+	GenreID    string
+	Conditions []models.AlbumCondition
+	MinRating  int
+	MaxRating  int
+	MinYear    int
+	MaxYear    int
+
+	// Query further narrows the albums down, see ParseAlbumQuery
+	Query *AlbumQuery
+}
+
+// withDefaults fills in the default page size, sort field and order
+func (f AlbumFilter) withDefaults() AlbumFilter {
+	if f.Limit == 0 {
+		f.Limit = DefaultAlbumLimit
+	}
+	if f.Sort == "" {
+		f.Sort = "title"
+	}
+	if f.Order == "" {
+		f.Order = "asc"
+	}
+	return f
+}
+
+// Validate reports the first invalid option in the filter
+func (f AlbumFilter) Validate() error {
+	f = f.withDefaults()
+
+	if f.Limit < 1 || f.Limit > MaxAlbumLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxAlbumLimit)
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	if _, ok := albumSortColumns[f.Sort]; !ok {
+		return fmt.Errorf("unsupported sort field %q", f.Sort)
+	}
+	if f.Order != "asc" && f.Order != "desc" {
+		return fmt.Errorf("order must be asc or desc")
+	}
+	for _, condition := range f.Conditions {
+		if !condition.IsValid() {
+			return fmt.Errorf("unknown condition %q", condition)
+		}
+	}
+	if f.MinRating != 0 && f.MaxRating != 0 && f.MinRating > f.MaxRating {
+		return fmt.Errorf("min_rating must not be greater than max_rating")
+	}
+	if f.MinYear != 0 && f.MaxYear != 0 && f.MinYear > f.MaxYear {
+		return fmt.Errorf("min_year must not be greater than max_year")
+	}
+
+	return nil
+}
+
+// whereClause builds the WHERE clause and its positional arguments
+func (f AlbumFilter) whereClause() (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	add := func(clause string, arg interface{}) {
+		args = append(args, arg)
+		clauses = append(clauses, fmt.Sprintf(clause, len(args)))
+	}
+
+	if f.ArtistID != "" {
+		add("a.artist_id = $%d", f.ArtistID)
+	}
+	if f.GenreID != "" {
+		add("a.genre_id = $%d", f.GenreID)
+	}
+	if len(f.Conditions) > 0 {
+		placeholders := make([]string, len(f.Conditions))
+		for i, condition := range f.Conditions {
+			args = append(args, condition)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		clauses = append(clauses, "a.condition IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if f.MinRating != 0 {
+		add("a.rating >= $%d", f.MinRating)
+	}
+	if f.MaxRating != 0 {
+		add("a.rating <= $%d", f.MaxRating)
+	}
+	// release_year is stored as text, so years are compared as zero-padded strings
+	if f.MinYear != 0 {
+		add("a.release_year >= $%d", fmt.Sprintf("%04d", f.MinYear))
+	}
+	if f.MaxYear != 0 {
+		add("a.release_year <= $%d", fmt.Sprintf("%04d", f.MaxYear))
+	}
+
+	if f.Query != nil {
+		where := f.Query.where(func(value interface{}) string {
+			args = append(args, value)
+			return fmt.Sprintf("$%d", len(args))
+		})
+		if where != "" {
+			clauses = append(clauses, where)
+		}
+	}
+
+	if len(clauses) == 0 {
+		return "", args
+	}
+	return "\n\t\tWHERE " + strings.Join(clauses, " AND "), args
+}
+
+// matches reports whether an album passes the filter; it mirrors whereClause for stores without SQL
+func (f AlbumFilter) matches(album *models.Album) bool {
+	if f.ArtistID != "" && album.ArtistID != f.ArtistID {
+		return false
+	}
+	if f.GenreID != "" && album.GenreID != f.GenreID {
+		return false
+	}
+	if len(f.Conditions) > 0 && !slices.Contains(f.Conditions, album.Condition) {
+		return false
+	}
+	if f.MinRating != 0 && album.Rating < f.MinRating {
+		return false
+	}
+	if f.MaxRating != 0 && album.Rating > f.MaxRating {
+		return false
+	}
+	if f.MinYear != 0 && album.ReleaseYear < fmt.Sprintf("%04d", f.MinYear) {
+		return false
+	}
+	if f.MaxYear != 0 && album.ReleaseYear > fmt.Sprintf("%04d", f.MaxYear) {
+		return false
+	}
+	if f.Query != nil && !f.Query.matches(album) {
+		return false
+	}
+	return true
+}
+
+// compare orders two albums by the sort field and direction, mirroring orderClause
+func (f AlbumFilter) compare(a, b *models.Album) int {
+	var result int
+	switch f.Sort {
+	case "artist":
+		result = strings.Compare(a.Artist.Name, b.Artist.Name)
+	case "release_year":
+		result = strings.Compare(a.ReleaseYear, b.ReleaseYear)
+	case "rating":
+		result = cmp.Compare(a.Rating, b.Rating)
+	case "condition":
+		result = cmp.Compare(slices.Index(models.AlbumConditions, a.Condition), slices.Index(models.AlbumConditions, b.Condition))
+	default:
+		result = strings.Compare(a.Title, b.Title)
+	}
+	if result == 0 {
+		result = strings.Compare(a.ID, b.ID)
+	}
+
+	if f.Order == "desc" {
+		return -result
+	}
+	return result
+}
+
+// orderClause builds the ORDER BY clause, using the album ID as a tie-breaker
+// so that pages stay stable between requests
+func (f AlbumFilter) orderClause() string {
+	direction := "ASC"
+	if f.Order == "desc" {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("\n\t\tORDER BY %s %s, a.id %s", albumSortColumns[f.Sort], direction, direction)
+}
+
+// newAlbumPage wraps a page of albums with its pagination metadata
+func newAlbumPage(albums []models.Album, total int, filter AlbumFilter) *models.AlbumPage {
+	page := &models.AlbumPage{
+		Items:  albums,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if next := filter.Offset + len(albums); next < total {
+		page.NextOffset = &next
+	}
+	return page
+}
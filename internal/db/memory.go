@@ -0,0 +1,599 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+// MemoryStore keeps the collection in memory, for local development and tests.
+// It enforces the same primary key, foreign key and CHECK constraints as the Postgres schema
+// and reports violations the same way. Its operations never wait, so contexts are ignored.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	albums      map[string]models.Album
+	artists     map[string]models.Artist
+	genres      map[string]models.Genre
+	collections map[string]models.Collection
+}
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		albums:      map[string]models.Album{},
+		artists:     map[string]models.Artist{},
+		genres:      map[string]models.Genre{},
+		collections: map[string]models.Collection{},
+	}
+}
+
+// Close is a no-op; the data lives as long as the store does
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// GetAlbums retrieves a page of albums matching the filter
+func (s *MemoryStore) GetAlbums(ctx context.Context, filter AlbumFilter) (*models.AlbumPage, error) {
+	filter = filter.withDefaults()
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []models.Album
+	for _, album := range s.albums {
+		album = s.joinAlbum(album)
+		if filter.matches(&album) {
+			matches = append(matches, album)
+		}
+	}
+	slices.SortFunc(matches, func(a, b models.Album) int {
+		return filter.compare(&a, &b)
+	})
+
+	albums := []models.Album{}
+	if filter.Offset < len(matches) {
+		end := min(filter.Offset+filter.Limit, len(matches))
+		albums = append(albums, matches[filter.Offset:end]...)
+	}
+
+	return newAlbumPage(albums, len(matches), filter), nil
+}
+
+// CountAlbums counts the albums
+func (s *MemoryStore) CountAlbums(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.albums), nil
+}
+
+// GetAlbumByID retrieves a single album by ID
+func (s *MemoryStore) GetAlbumByID(ctx context.Context, id string) (*models.Album, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	album, ok := s.albums[id]
+	if !ok {
+		return nil, nil // No album found
+	}
+
+	album = s.joinAlbum(album)
+	return &album, nil
+}
+
+// CreateAlbum adds a new album
+func (s *MemoryStore) CreateAlbum(ctx context.Context, album models.Album) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Postgres checks the row itself, then the primary key and finally the foreign keys
+	if err := checkAlbumValues(album); err != nil {
+		return err
+	}
+	if _, exists := s.albums[album.ID]; exists {
+		return violation(ErrConflict, "albums_pkey")
+	}
+	if err := s.checkAlbumReferences(album); err != nil {
+		return err
+	}
+
+	s.albums[album.ID] = stripAlbum(album)
+	return nil
+}
+
+// UpdateAlbum updates an existing album
+func (s *MemoryStore) UpdateAlbum(ctx context.Context, album models.Album) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.albums[album.ID]; !exists {
+		return ErrNotFound
+	}
+	if err := s.checkAlbum(album); err != nil {
+		return err
+	}
+
+	s.albums[album.ID] = stripAlbum(album)
+	return nil
+}
+
+// PatchAlbum updates only the fields supplied in the patch. An empty patch is a no-op.
+func (s *MemoryStore) PatchAlbum(ctx context.Context, id string, patch, expected models.AlbumPatch) error {
+	if patch.IsEmpty() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	album, exists := s.albums[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if !albumHas(album, expected) {
+		return ErrModified
+	}
+
+	if patch.Title != nil {
+		album.Title = *patch.Title
+	}
+	if patch.ArtistID != nil {
+		album.ArtistID = *patch.ArtistID
+	}
+	if patch.ReleaseYear != nil {
+		album.ReleaseYear = *patch.ReleaseYear
+	}
+	if patch.GenreID != nil {
+		album.GenreID = *patch.GenreID
+	}
+	if patch.Notes != nil {
+		album.Notes = *patch.Notes
+	}
+	if patch.Rating != nil {
+		album.Rating = *patch.Rating
+	}
+	if patch.Condition != nil {
+		album.Condition = *patch.Condition
+	}
+
+	if err := s.checkAlbum(album); err != nil {
+		return err
+	}
+
+	s.albums[id] = album
+	return nil
+}
+
+// albumHas reports whether the album has every non-nil value of expected
+func albumHas(album models.Album, expected models.AlbumPatch) bool {
+	return (expected.Title == nil || *expected.Title == album.Title) &&
+		(expected.ArtistID == nil || *expected.ArtistID == album.ArtistID) &&
+		(expected.ReleaseYear == nil || *expected.ReleaseYear == album.ReleaseYear) &&
+		(expected.GenreID == nil || *expected.GenreID == album.GenreID) &&
+		(expected.Notes == nil || *expected.Notes == album.Notes) &&
+		(expected.Rating == nil || *expected.Rating == album.Rating) &&
+		(expected.Condition == nil || *expected.Condition == album.Condition)
+}
+
+// DeleteAlbum removes an album, taking it out of every manual collection
+func (s *MemoryStore) DeleteAlbum(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.albums[id]; !exists {
+		return ErrNotFound
+	}
+
+	delete(s.albums, id)
+	for collectionID, collection := range s.collections {
+		if slices.Contains(collection.AlbumIDs, id) {
+			collection.AlbumIDs = slices.DeleteFunc(slices.Clone(collection.AlbumIDs), func(albumID string) bool {
+				return albumID == id
+			})
+			s.collections[collectionID] = collection
+		}
+	}
+	return nil
+}
+
+// GetArtists retrieves all artists, ordered by ID
+func (s *MemoryStore) GetArtists(ctx context.Context) ([]models.Artist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artists := []models.Artist{}
+	for _, artist := range s.artists {
+		artists = append(artists, artist)
+	}
+	slices.SortFunc(artists, func(a, b models.Artist) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return artists, nil
+}
+
+// CountArtists counts the artists
+func (s *MemoryStore) CountArtists(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.artists), nil
+}
+
+// GetArtistByID retrieves a single artist by ID
+func (s *MemoryStore) GetArtistByID(ctx context.Context, id string) (*models.Artist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artist, ok := s.artists[id]
+	if !ok {
+		return nil, nil // No artist found
+	}
+	return &artist, nil
+}
+
+// CreateArtist adds a new artist
+func (s *MemoryStore) CreateArtist(ctx context.Context, artist models.Artist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.artists[artist.ID]; exists {
+		return violation(ErrConflict, "artists_pkey")
+	}
+
+	s.artists[artist.ID] = artist
+	return nil
+}
+
+// UpdateArtist updates an existing artist
+func (s *MemoryStore) UpdateArtist(ctx context.Context, artist models.Artist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.artists[artist.ID]; !exists {
+		return ErrNotFound
+	}
+
+	s.artists[artist.ID] = artist
+	return nil
+}
+
+// PatchArtist updates only the fields supplied in the patch
+func (s *MemoryStore) PatchArtist(ctx context.Context, id string, patch models.ArtistPatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artist, exists := s.artists[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if patch.Name != nil {
+		artist.Name = *patch.Name
+	}
+
+	s.artists[id] = artist
+	return nil
+}
+
+// DeleteArtist removes an artist; artists that still have albums are never deleted
+func (s *MemoryStore) DeleteArtist(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if albums := s.countAlbums(func(album models.Album) bool { return album.ArtistID == id }); albums > 0 {
+		return fmt.Errorf("%w: artist %s still has %d album(s)", ErrConflict, id, albums)
+	}
+	if _, exists := s.artists[id]; !exists {
+		return ErrNotFound
+	}
+
+	delete(s.artists, id)
+	return nil
+}
+
+// GetGenres retrieves all genres, ordered by ID
+func (s *MemoryStore) GetGenres(ctx context.Context) ([]models.Genre, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	genres := []models.Genre{}
+	for _, genre := range s.genres {
+		genres = append(genres, genre)
+	}
+	slices.SortFunc(genres, func(a, b models.Genre) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return genres, nil
+}
+
+// CountGenres counts the genres
+func (s *MemoryStore) CountGenres(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.genres), nil
+}
+
+// GetGenreByID retrieves a single genre by ID
+func (s *MemoryStore) GetGenreByID(ctx context.Context, id string) (*models.Genre, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	genre, ok := s.genres[id]
+	if !ok {
+		return nil, nil // No genre found
+	}
+	return &genre, nil
+}
+
+// CreateGenre adds a new genre
+func (s *MemoryStore) CreateGenre(ctx context.Context, genre models.Genre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.genres[genre.ID]; exists {
+		return violation(ErrConflict, "genres_pkey")
+	}
+
+	s.genres[genre.ID] = genre
+	return nil
+}
+
+// UpdateGenre updates an existing genre, including its icon
+func (s *MemoryStore) UpdateGenre(ctx context.Context, genre models.Genre) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.genres[genre.ID]; !exists {
+		return ErrNotFound
+	}
+
+	s.genres[genre.ID] = genre
+	return nil
+}
+
+// PatchGenre updates only the fields supplied in the patch
+func (s *MemoryStore) PatchGenre(ctx context.Context, id string, patch models.GenrePatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	genre, exists := s.genres[id]
+	if !exists {
+		return ErrNotFound
+	}
+	if patch.Name != nil {
+		genre.Name = *patch.Name
+	}
+	if patch.Icon != nil {
+		genre.Icon = *patch.Icon
+	}
+
+	s.genres[id] = genre
+	return nil
+}
+
+// DeleteGenre removes a genre; genres that still have albums are never deleted
+func (s *MemoryStore) DeleteGenre(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if albums := s.countAlbums(func(album models.Album) bool { return album.GenreID == id }); albums > 0 {
+		return fmt.Errorf("%w: genre %s still has %d album(s)", ErrConflict, id, albums)
+	}
+	if _, exists := s.genres[id]; !exists {
+		return ErrNotFound
+	}
+
+	delete(s.genres, id)
+	return nil
+}
+
+// GetCollections retrieves all collections, ordered by name
+func (s *MemoryStore) GetCollections(ctx context.Context) ([]models.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collections := make([]models.Collection, 0, len(s.collections))
+	for _, collection := range s.collections {
+		collections = append(collections, cloneCollection(collection))
+	}
+	slices.SortFunc(collections, func(a, b models.Collection) int {
+		if a.Name != b.Name {
+			return strings.Compare(a.Name, b.Name)
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return collections, nil
+}
+
+// GetCollectionByID retrieves a single collection by ID
+func (s *MemoryStore) GetCollectionByID(ctx context.Context, id string) (*models.Collection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collection, ok := s.collections[id]
+	if !ok {
+		return nil, nil
+	}
+	collection = cloneCollection(collection)
+	return &collection, nil
+}
+
+// CreateCollection adds a new collection
+func (s *MemoryStore) CreateCollection(ctx context.Context, collection models.Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.collections[collection.ID]; exists {
+		return violation(ErrConflict, "collections_pkey")
+	}
+	if err := s.checkCollection(collection); err != nil {
+		return err
+	}
+
+	s.collections[collection.ID] = cloneCollection(collection)
+	return nil
+}
+
+// UpdateCollection replaces an existing collection
+func (s *MemoryStore) UpdateCollection(ctx context.Context, collection models.Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.collections[collection.ID]; !exists {
+		return ErrNotFound
+	}
+	if err := s.checkCollection(collection); err != nil {
+		return err
+	}
+
+	s.collections[collection.ID] = cloneCollection(collection)
+	return nil
+}
+
+// DeleteCollection removes a collection; its albums are left alone
+func (s *MemoryStore) DeleteCollection(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.collections[id]; !exists {
+		return ErrNotFound
+	}
+
+	delete(s.collections, id)
+	return nil
+}
+
+// GetCollectionAlbums retrieves a page of the albums of a manual collection matching the filter
+func (s *MemoryStore) GetCollectionAlbums(ctx context.Context, id string, filter AlbumFilter) (*models.AlbumPage, error) {
+	byPosition := filter.Sort == ""
+	filter = filter.withDefaults()
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matches []models.Album
+	for _, albumID := range s.collections[id].AlbumIDs {
+		album := s.joinAlbum(s.albums[albumID])
+		if filter.matches(&album) {
+			matches = append(matches, album)
+		}
+	}
+	if !byPosition {
+		slices.SortFunc(matches, func(a, b models.Album) int {
+			return filter.compare(&a, &b)
+		})
+	} else if filter.Order == "desc" {
+		slices.Reverse(matches)
+	}
+
+	albums := []models.Album{}
+	if filter.Offset < len(matches) {
+		end := min(filter.Offset+filter.Limit, len(matches))
+		albums = append(albums, matches[filter.Offset:end]...)
+	}
+
+	return newAlbumPage(albums, len(matches), filter), nil
+}
+
+// checkCollection enforces the CHECK and foreign key constraints of the collection tables.
+// The caller must hold the lock.
+func (s *MemoryStore) checkCollection(collection models.Collection) error {
+	if collection.Kind != models.CollectionSmart && collection.Kind != models.CollectionManual {
+		return violation(ErrInvalidValue, "collections_kind_check")
+	}
+	for i, albumID := range collection.AlbumIDs {
+		if _, ok := s.albums[albumID]; !ok {
+			return violation(ErrInvalidReference, "collection_albums_album_id_fkey")
+		}
+		if slices.Contains(collection.AlbumIDs[:i], albumID) {
+			return violation(ErrConflict, "collection_albums_pkey")
+		}
+	}
+	return nil
+}
+
+// cloneCollection copies a collection so that callers can't change the stored album IDs
+func cloneCollection(collection models.Collection) models.Collection {
+	collection.AlbumIDs = slices.Clone(collection.AlbumIDs)
+	return collection
+}
+
+// checkAlbum enforces the CHECK and foreign key constraints of the albums table.
+// The caller must hold the lock.
+func (s *MemoryStore) checkAlbum(album models.Album) error {
+	if err := checkAlbumValues(album); err != nil {
+		return err
+	}
+	return s.checkAlbumReferences(album)
+}
+
+// checkAlbumValues enforces the CHECK constraints of the albums table
+func checkAlbumValues(album models.Album) error {
+	if album.Rating < 1 || album.Rating > 5 {
+		return violation(ErrInvalidValue, "albums_rating_check")
+	}
+	if !album.Condition.IsValid() {
+		return violation(ErrInvalidValue, "albums_condition_check")
+	}
+	return nil
+}
+
+// checkAlbumReferences enforces the foreign keys of the albums table. The caller must hold the lock.
+func (s *MemoryStore) checkAlbumReferences(album models.Album) error {
+	if _, ok := s.artists[album.ArtistID]; !ok {
+		return violation(ErrInvalidReference, "albums_artist_id_fkey")
+	}
+	if _, ok := s.genres[album.GenreID]; !ok {
+		return violation(ErrInvalidReference, "albums_genre_id_fkey")
+	}
+	return nil
+}
+
+// joinAlbum attaches the album's artist and genre, like the join in the Postgres queries.
+// The caller must hold the lock.
+func (s *MemoryStore) joinAlbum(album models.Album) models.Album {
+	artist := s.artists[album.ArtistID]
+	genre := s.genres[album.GenreID]
+	album.Artist = &artist
+	album.Genre = &genre
+	return album
+}
+
+// countAlbums counts the albums matching the predicate. The caller must hold the lock.
+func (s *MemoryStore) countAlbums(match func(models.Album) bool) int {
+	count := 0
+	for _, album := range s.albums {
+		if match(album) {
+			count++
+		}
+	}
+	return count
+}
+
+// stripAlbum drops the joined artist and genre before an album is stored,
+// so that later artist and genre changes show up on reads
+func stripAlbum(album models.Album) models.Album {
+	album.Artist = nil
+	album.Genre = nil
+	return album
+}
+
+// Search finds albums, artists and genres matching the query, best match first
+func (s *MemoryStore) Search(ctx context.Context, query SearchQuery) (*models.SearchPage, error) {
+	return searchStore(ctx, s, query)
+}
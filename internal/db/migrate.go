@@ -0,0 +1,460 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Each store has its own flavour of the schema, with the same versions
+//
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer version of VinylVault
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+// ErrSchemaOutdated is returned when the database is missing migrations known to this binary
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// Migration is one versioned schema change, read from a pair of
+// NNNNNN_name.up.sql and NNNNNN_name.down.sql files
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied to the database
+type MigrationStatus struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// Migratable is implemented by stores whose schema is managed by migrations
+type Migratable interface {
+	Migrator() (*Migrator, error)
+}
+
+// versionReader reads the schema version of a database
+type versionReader interface {
+	// version returns the applied version, 0 for an empty database. It only reads, so it
+	// works for a database role without the right to create tables.
+	version(ctx context.Context) (int, error)
+}
+
+// migrationTarget records and changes the schema version of one kind of database
+type migrationTarget interface {
+	versionReader
+	// lock creates the version table if it doesn't exist yet and keeps other migrators out
+	// until the session is unlocked. It waits for a migrator that holds the lock already.
+	lock(ctx context.Context) (migrationSession, error)
+}
+
+// migrationSession changes the schema while holding the migration lock
+type migrationSession interface {
+	versionReader
+	// apply runs script and records to as the applied version in a single transaction.
+	// It fails if the database is no longer at version from.
+	apply(ctx context.Context, script string, from, to int) error
+	unlock(ctx context.Context)
+}
+
+// Migrator applies the embedded migrations to a database, one transaction per migration
+type Migrator struct {
+	target     migrationTarget
+	migrations []Migration
+}
+
+// newMigrator loads the migrations in dir of files
+func newMigrator(target migrationTarget, files fs.FS, dir string) (*Migrator, error) {
+	migrations, err := loadMigrations(files, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{target: target, migrations: migrations}, nil
+}
+
+// loadMigrations reads the up and down scripts in dir, sorted by version
+func loadMigrations(files fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if entry.IsDir() || !ok || (direction != "up" && direction != "down") {
+			continue
+		}
+		number, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s doesn't start with a version number", entry.Name())
+		}
+
+		script, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names, %q and %q", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d needs both an up and a down script", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Latest returns the newest version known to this binary
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the version the database is at, 0 if no migration has been applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	return m.target.version(ctx)
+}
+
+// Up applies every pending migration and returns the ones it applied. The pending
+// migrations are read under the migration lock, so a server that starts while another
+// one migrates waits for it and then applies whatever is left.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	session, err := m.target.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.unlock(ctx)
+
+	current, err := m.checkedVersion(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= current {
+			continue
+		}
+		if err := session.apply(ctx, migration.Up, current, migration.Version); err != nil {
+			return applied, fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+		current = migration.Version
+	}
+
+	return applied, nil
+}
+
+// Down reverts the last steps applied migrations and returns the ones it reverted, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	session, err := m.target.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.unlock(ctx)
+
+	current, err := m.checkedVersion(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current {
+			continue
+		}
+
+		previous := 0
+		if i > 0 {
+			previous = m.migrations[i-1].Version
+		}
+		if err := session.apply(ctx, migration.Down, current, previous); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+		current = previous
+	}
+
+	return reverted, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	current, err := m.target.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		}
+	}
+	return statuses, nil
+}
+
+// CheckVersion verifies that the database is at exactly the version this binary expects.
+// It returns ErrSchemaTooNew or ErrSchemaOutdated otherwise.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	current, err := m.checkedVersion(ctx, m.target)
+	if err != nil {
+		return err
+	}
+	if current < m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, this binary needs version %d", ErrSchemaOutdated, current, m.Latest())
+	}
+	return nil
+}
+
+// checkedVersion returns the database version, failing with ErrSchemaTooNew
+// if it is newer than any migration this binary knows about
+func (m *Migrator) checkedVersion(ctx context.Context, reader versionReader) (int, error) {
+	current, err := reader.version(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if current > m.Latest() {
+		return current, fmt.Errorf("%w: database is at version %d, this binary only knows up to version %d", ErrSchemaTooNew, current, m.Latest())
+	}
+	return current, nil
+}
+
+// createSchemaMigrations creates the version table used by golang-migrate, so databases
+// set up with the migrate CLI carry on from the version they are at
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+
+// selectMigrationVersion reads the version recorded in schema_migrations
+const selectMigrationVersion = "SELECT version, dirty FROM schema_migrations LIMIT 1"
+
+// pgUndefinedTable is the Postgres error code of a query on a table that doesn't exist
+const pgUndefinedTable = "42P01"
+
+// migrationLockID identifies the advisory lock that keeps two servers from migrating at once
+const migrationLockID = 7316484
+
+// scanMigrationVersion reads the row of schema_migrations, if any
+func scanMigrationVersion(row pgx.Row) (int, error) {
+	var version int
+	var dirty bool
+	err := row.Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return version, fmt.Errorf("database is dirty at version %d: a migration failed halfway, fix the schema and clear the dirty flag in schema_migrations", version)
+	}
+	return version, nil
+}
+
+// checkMigrationVersion fails if another process migrated the database in the meantime
+func checkMigrationVersion(current, from int) error {
+	if current != from {
+		return fmt.Errorf("database moved from version %d to %d while migrating", from, current)
+	}
+	return nil
+}
+
+// PostgresConn runs statements on a single Postgres connection,
+// e.g. a *pgx.Conn, a *pgxpool.Conn or a mock
+type PostgresConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// NewPostgresMigrator migrates the database behind conn, running every statement on it
+func NewPostgresMigrator(conn PostgresConn) (*Migrator, error) {
+	return newMigrator(postgresMigrationTarget{
+		conn:    conn,
+		acquire: func(ctx context.Context) (PostgresConn, func(bool), error) { return conn, func(bool) {}, nil },
+	}, postgresMigrations, "migrations/postgres")
+}
+
+// postgresMigrationTarget migrates a Postgres database
+type postgresMigrationTarget struct {
+	// conn reads the version outside of the migration lock
+	conn PostgresConn
+	// acquire hands out the connection holding the migration lock. release is told whether
+	// the lock was given up, and must not reuse the connection otherwise.
+	acquire func(ctx context.Context) (conn PostgresConn, release func(unlocked bool), err error)
+}
+
+func (t postgresMigrationTarget) version(ctx context.Context) (int, error) {
+	return postgresMigrationVersion(ctx, t.conn)
+}
+
+// lock takes a session-level advisory lock, held across the transactions of the migrations
+func (t postgresMigrationTarget) lock(ctx context.Context) (migrationSession, error) {
+	conn, release, err := t.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		release(false)
+		return nil, err
+	}
+
+	session := postgresMigrationSession{conn: conn, release: release}
+	if _, err := conn.Exec(ctx, createSchemaMigrations); err != nil {
+		session.unlock(ctx)
+		return nil, err
+	}
+	return session, nil
+}
+
+// postgresMigrationVersion reads schema_migrations, which doesn't exist before the first migration
+func postgresMigrationVersion(ctx context.Context, conn PostgresConn) (int, error) {
+	version, err := scanMigrationVersion(conn.QueryRow(ctx, selectMigrationVersion))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUndefinedTable {
+		return 0, nil
+	}
+	return version, err
+}
+
+// postgresMigrationSession migrates a Postgres database on the connection holding the lock
+type postgresMigrationSession struct {
+	conn    PostgresConn
+	release func(unlocked bool)
+}
+
+func (s postgresMigrationSession) version(ctx context.Context) (int, error) {
+	return postgresMigrationVersion(ctx, s.conn)
+}
+
+func (s postgresMigrationSession) apply(ctx context.Context, script string, from, to int) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanMigrationVersion(tx.QueryRow(ctx, selectMigrationVersion))
+	if err != nil {
+		return err
+	}
+	if err := checkMigrationVersion(current, from); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if to > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", to); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// unlock gives up the lock even when ctx is done, so the connection can go back to the pool
+func (s postgresMigrationSession) unlock(ctx context.Context) {
+	_, err := s.conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID)
+	s.release(err == nil)
+}
+
+// sqliteMigrationTarget migrates a SQLite database
+type sqliteMigrationTarget struct {
+	db *sql.DB
+}
+
+// version reads schema_migrations, which doesn't exist before the first migration
+func (t sqliteMigrationTarget) version(ctx context.Context) (int, error) {
+	version, err := scanMigrationVersion(t.db.QueryRowContext(ctx, selectMigrationVersion))
+	if err != nil && strings.Contains(err.Error(), "no such table: schema_migrations") {
+		return 0, nil
+	}
+	return version, err
+}
+
+// lock only creates the version table: the store has a single connection, and another
+// process migrating the same file is caught by the version check in apply
+func (t sqliteMigrationTarget) lock(ctx context.Context) (migrationSession, error) {
+	if _, err := t.db.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t sqliteMigrationTarget) unlock(ctx context.Context) {}
+
+func (t sqliteMigrationTarget) apply(ctx context.Context, script string, from, to int) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := scanMigrationVersion(tx.QueryRowContext(ctx, selectMigrationVersion))
+	if err != nil {
+		return err
+	}
+	if err := checkMigrationVersion(current, from); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if to > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", to); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS genres_name_trgm_idx;
+DROP INDEX IF EXISTS artists_name_trgm_idx;
+DROP INDEX IF EXISTS albums_title_trgm_idx;
+
+DROP TRIGGER IF EXISTS genres_search_refresh ON genres;
+DROP TRIGGER IF EXISTS artists_search_refresh ON artists;
+DROP TRIGGER IF EXISTS albums_search_update ON albums;
+DROP FUNCTION IF EXISTS albums_search_refresh();
+DROP FUNCTION IF EXISTS albums_search_update();
+DROP FUNCTION IF EXISTS album_search_vector(albums);
+
+-- Dropping the columns drops their GIN indexes too
+ALTER TABLE albums DROP COLUMN IF EXISTS search;
+ALTER TABLE genres DROP COLUMN IF EXISTS search;
+ALTER TABLE artists DROP COLUMN IF EXISTS search;
+
+-- pg_trgm is left installed; other database objects may have come to rely on it
@@ -0,0 +1,63 @@
+-- Full-text search over albums, artists and genres, with trigram indexes to forgive typos.
+-- Album vectors combine the title (A), artist (B), genre (C) and notes (D), so they are
+-- maintained by triggers rather than generated from the album row alone.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE artists ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('english', name), 'A')) STORED;
+
+ALTER TABLE genres ADD COLUMN search tsvector
+    GENERATED ALWAYS AS (setweight(to_tsvector('english', name), 'A')) STORED;
+
+ALTER TABLE albums ADD COLUMN search tsvector;
+
+CREATE FUNCTION album_search_vector(album albums) RETURNS tsvector
+LANGUAGE sql STABLE AS $$
+    SELECT setweight(to_tsvector('english', album.title), 'A')
+        || setweight(to_tsvector('english', coalesce((SELECT name FROM artists WHERE id = album.artist_id), '')), 'B')
+        || setweight(to_tsvector('english', coalesce((SELECT name FROM genres WHERE id = album.genre_id), '')), 'C')
+        || setweight(to_tsvector('english', coalesce(album.notes, '')), 'D')
+$$;
+
+CREATE FUNCTION albums_search_update() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search := album_search_vector(NEW);
+    RETURN NEW;
+END
+$$;
+
+CREATE TRIGGER albums_search_update
+    BEFORE INSERT OR UPDATE OF title, artist_id, genre_id, notes ON albums
+    FOR EACH ROW EXECUTE FUNCTION albums_search_update();
+
+-- Renaming an artist or genre refreshes the vectors of its albums
+CREATE FUNCTION albums_search_refresh() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'artists' THEN
+        UPDATE albums SET search = album_search_vector(albums) WHERE artist_id = NEW.id;
+    ELSE
+        UPDATE albums SET search = album_search_vector(albums) WHERE genre_id = NEW.id;
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+CREATE TRIGGER artists_search_refresh
+    AFTER UPDATE OF name ON artists
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION albums_search_refresh();
+
+CREATE TRIGGER genres_search_refresh
+    AFTER UPDATE OF name ON genres
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name) EXECUTE FUNCTION albums_search_refresh();
+
+UPDATE albums SET search = album_search_vector(albums);
+
+CREATE INDEX albums_search_idx ON albums USING GIN (search);
+CREATE INDEX artists_search_idx ON artists USING GIN (search);
+CREATE INDEX genres_search_idx ON genres USING GIN (search);
+
+CREATE INDEX albums_title_trgm_idx ON albums USING GIN (title gin_trgm_ops);
+CREATE INDEX artists_name_trgm_idx ON artists USING GIN (name gin_trgm_ops);
+CREATE INDEX genres_name_trgm_idx ON genres USING GIN (name gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS collection_albums;
+DROP TABLE IF EXISTS collections;
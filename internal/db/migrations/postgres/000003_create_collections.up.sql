@@ -0,0 +1,19 @@
+-- Smart collections save an album query; manual collections list their albums in collection_albums.
+-- Deleting an album takes it out of every manual collection.
+CREATE TABLE IF NOT EXISTS collections (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('smart', 'manual')),
+    query TEXT NOT NULL DEFAULT '',
+    sort TEXT NOT NULL DEFAULT '',
+    sort_order TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS collection_albums (
+    collection_id TEXT NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    album_id TEXT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, album_id)
+);
+
+CREATE INDEX IF NOT EXISTS collection_albums_album_id_idx ON collection_albums (album_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS albums;
+DROP TABLE IF EXISTS genres;
+DROP TABLE IF EXISTS artists;
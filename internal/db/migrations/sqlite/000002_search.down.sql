@@ -0,0 +1,2 @@
+-- Nothing to revert, see 000002_search.up.sql
+SELECT 1;
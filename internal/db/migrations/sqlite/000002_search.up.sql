@@ -0,0 +1,4 @@
+-- Counterpart of ../postgres/000002_search.up.sql. SQLite stores are searched in Go
+-- (see SQLiteStore.Search), so there are no search columns or indexes to create.
+-- The migration exists to keep the schema versions of both databases in step.
+SELECT 1;
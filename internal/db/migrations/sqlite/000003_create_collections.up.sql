@@ -0,0 +1,20 @@
+-- SQLite mirror of ../postgres/000003_create_collections.up.sql, with the constraint names Postgres generates
+CREATE TABLE IF NOT EXISTS collections (
+    id TEXT NOT NULL CONSTRAINT collections_pkey PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CONSTRAINT collections_kind_check CHECK (kind IN ('smart', 'manual')),
+    query TEXT NOT NULL DEFAULT '',
+    sort TEXT NOT NULL DEFAULT '',
+    sort_order TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS collection_albums (
+    collection_id TEXT NOT NULL,
+    album_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    CONSTRAINT collection_albums_pkey PRIMARY KEY (collection_id, album_id),
+    CONSTRAINT collection_albums_collection_id_fkey FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    CONSTRAINT collection_albums_album_id_fkey FOREIGN KEY (album_id) REFERENCES albums(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS collection_albums_album_id_idx ON collection_albums (album_id);
@@ -0,0 +1,25 @@
+-- SQLite mirror of ../postgres/000004_remove_sample_data.down.sql
+-- Put back the sample records of migration 1, leaving any record with the same ID alone
+INSERT INTO artists (id, name) VALUES
+    ('art-001', 'Pink Floyd'),
+    ('art-002', 'David Bowie'),
+    ('art-003', 'Miles Davis'),
+    ('art-004', 'Nirvana'),
+    ('art-005', 'Radiohead')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO genres (id, name, icon) VALUES
+    ('gen-001', 'Rock', '🎸'),
+    ('gen-002', 'Jazz', '🎷'),
+    ('gen-003', 'Electronic', '🎹'),
+    ('gen-004', 'Hip Hop', '🎤'),
+    ('gen-005', 'Classical', '🎻')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO albums (id, title, artist_id, release_year, genre_id, notes, rating, condition) VALUES
+    ('alb-001', 'The Dark Side of the Moon', 'art-001', '1973', 'gen-001', 'Original pressing with posters and stickers', 5, 'Excellent'),
+    ('alb-002', 'Kind of Blue', 'art-003', '1959', 'gen-002', 'Columbia Records pressing from the 1970s', 5, 'Very Good'),
+    ('alb-003', 'OK Computer', 'art-005', '1997', 'gen-001', 'Special edition with artwork booklet', 4, 'Mint'),
+    ('alb-004', 'Nevermind', 'art-004', '1991', 'gen-001', 'First pressing with insert', 4, 'Good'),
+    ('alb-005', 'Ziggy Stardust', 'art-002', '1972', 'gen-001', 'Original RCA pressing', 5, 'Very Good')
+ON CONFLICT (id) DO NOTHING;
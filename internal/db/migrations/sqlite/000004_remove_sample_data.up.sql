@@ -0,0 +1,28 @@
+-- SQLite mirror of ../postgres/000004_remove_sample_data.up.sql
+-- Sample data comes from the seed command now, but migration 1 used to insert it.
+-- Remove the sample records that are still exactly as inserted; edited ones are kept,
+-- and so are sample artists and genres that other albums refer to.
+DELETE FROM albums WHERE (id, title, artist_id, release_year, genre_id, notes, rating, condition) IN (VALUES
+    ('alb-001', 'The Dark Side of the Moon', 'art-001', '1973', 'gen-001', 'Original pressing with posters and stickers', 5, 'Excellent'),
+    ('alb-002', 'Kind of Blue', 'art-003', '1959', 'gen-002', 'Columbia Records pressing from the 1970s', 5, 'Very Good'),
+    ('alb-003', 'OK Computer', 'art-005', '1997', 'gen-001', 'Special edition with artwork booklet', 4, 'Mint'),
+    ('alb-004', 'Nevermind', 'art-004', '1991', 'gen-001', 'First pressing with insert', 4, 'Good'),
+    ('alb-005', 'Ziggy Stardust', 'art-002', '1972', 'gen-001', 'Original RCA pressing', 5, 'Very Good'));
+
+DELETE FROM artists
+WHERE (id, name) IN (VALUES
+    ('art-001', 'Pink Floyd'),
+    ('art-002', 'David Bowie'),
+    ('art-003', 'Miles Davis'),
+    ('art-004', 'Nirvana'),
+    ('art-005', 'Radiohead'))
+AND NOT EXISTS (SELECT 1 FROM albums WHERE albums.artist_id = artists.id);
+
+DELETE FROM genres
+WHERE (id, name, icon) IN (VALUES
+    ('gen-001', 'Rock', '🎸'),
+    ('gen-002', 'Jazz', '🎷'),
+    ('gen-003', 'Electronic', '🎹'),
+    ('gen-004', 'Hip Hop', '🎤'),
+    ('gen-005', 'Classical', '🎻'))
+AND NOT EXISTS (SELECT 1 FROM albums WHERE albums.genre_id = genres.id);
@@ -0,0 +1,36 @@
+package db
+
+import "strings"
+
+// QueryOperation names a query by its statement and main table, e.g. "select albums",
+// for metrics and traces. Unlike the SQL text, the names are few and stable.
+func QueryOperation(sql string) string {
+	words := strings.Fields(strings.ToLower(sql))
+	if len(words) == 0 {
+		return "unknown"
+	}
+
+	verb := words[0]
+	// The table follows the first FROM, INTO or UPDATE
+	after := map[string]string{"select": "from", "delete": "from", "insert": "into"}[verb]
+	if verb == "update" && len(words) > 1 {
+		return verb + " " + tableName(words[1])
+	}
+	if after == "" {
+		return verb
+	}
+	for i, word := range words[:len(words)-1] {
+		if word == after {
+			return verb + " " + tableName(words[i+1])
+		}
+	}
+	return verb
+}
+
+// tableName strips the punctuation that can follow a table name, e.g. "albums;" or "genres(id,"
+func tableName(word string) string {
+	if i := strings.IndexAny(word, "(;,)"); i >= 0 {
+		word = word[:i]
+	}
+	return word
+}
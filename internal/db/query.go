@@ -0,0 +1,493 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+// AlbumQuery is a parsed album query, e.g.
+//
+//	artist:"pink floyd" year:1970..1979 rating:>=4 condition:mint,excellent genre:rock -notes:reissue
+//
+// Terms are separated by spaces and must all match. A term is a field, a colon and one or more
+// comma-separated values, any of which may match; a leading - negates the term. Words without a
+// field match the title, artist, genre or notes. Text values match case-insensitively anywhere
+// in the field; "quoted values" may contain spaces and commas.
+//
+// year and rating take a number, a range such as 1970..1979 or 3.. or a comparison such as >=4.
+// condition takes condition names, with spaces written as - or _ (very-good), and comparisons
+// such as >=very-good, meaning Very Good or better.
+type AlbumQuery struct {
+	text  string
+	terms []queryTerm
+}
+
+// AlbumQueryError points at the token of an album query that couldn't be parsed
+type AlbumQueryError struct {
+	Column  int    // 1-based position of the token, in characters
+	Token   string // The offending token
+	Message string
+}
+
+func (e *AlbumQueryError) Error() string {
+	return fmt.Sprintf("%s (at column %d: %q)", e.Message, e.Column, e.Token)
+}
+
+// queryTerm is one space-separated term of a query; it matches when any of its values does
+type queryTerm struct {
+	negate     bool
+	conditions []queryCondition
+}
+
+// queryCondition is a compiled value: a condition on the albums join and its Go counterpart
+type queryCondition struct {
+	// where returns the SQL condition, calling arg to get the placeholder of each argument
+	where func(arg func(value interface{}) string) string
+	match func(album *models.Album) bool
+}
+
+// queryField compiles one value of a field, or describes why it's invalid
+type queryField func(value string) (queryCondition, error)
+
+// queryFields are the fields of the query language
+var queryFields = map[string]queryField{
+	"title":     textField("a.title", func(a *models.Album) string { return a.Title }),
+	"artist":    textField("ar.name", func(a *models.Album) string { return a.Artist.Name }),
+	"genre":     textField("g.name", func(a *models.Album) string { return a.Genre.Name }),
+	"notes":     textField("COALESCE(a.notes, '')", func(a *models.Album) string { return a.Notes }),
+	"year":      yearField,
+	"rating":    ratingField,
+	"condition": conditionField,
+}
+
+// queryFieldNames lists the fields for error messages
+func queryFieldNames() string {
+	names := make([]string, 0, len(queryFields))
+	for name := range queryFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// ParseAlbumQuery parses an album query. Errors are *AlbumQueryError.
+func ParseAlbumQuery(text string) (*AlbumQuery, error) {
+	p := queryParser{input: []rune(text)}
+	query := &AlbumQuery{text: text}
+
+	for {
+		p.skipSpace()
+		if p.done() {
+			return query, nil
+		}
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		query.terms = append(query.terms, term)
+	}
+}
+
+// And returns a query matching the albums that match both q and other; either may be nil
+func (q *AlbumQuery) And(other *AlbumQuery) *AlbumQuery {
+	if q == nil {
+		return other
+	}
+	if other == nil {
+		return q
+	}
+	return &AlbumQuery{text: q.text + " " + other.text, terms: append(slices.Clip(q.terms), other.terms...)}
+}
+
+// String returns the query as it was written
+func (q *AlbumQuery) String() string {
+	return q.text
+}
+
+// where returns the SQL condition of the whole query, or "" when it has no terms
+func (q *AlbumQuery) where(arg func(value interface{}) string) string {
+	clauses := make([]string, 0, len(q.terms))
+	for _, term := range q.terms {
+		alternatives := make([]string, len(term.conditions))
+		for i, condition := range term.conditions {
+			alternatives[i] = condition.where(arg)
+		}
+		clause := "(" + strings.Join(alternatives, " OR ") + ")"
+		if term.negate {
+			clause = "NOT " + clause
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// matches reports whether an album passes the query; it mirrors where for stores without SQL
+func (q *AlbumQuery) matches(album *models.Album) bool {
+	for _, term := range q.terms {
+		matched := slices.ContainsFunc(term.conditions, func(condition queryCondition) bool {
+			return condition.match(album)
+		})
+		if matched == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// queryParser reads the terms of a query one at a time
+type queryParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *queryParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *queryParser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// fail returns an error pointing at the token that starts at start
+func (p *queryParser) fail(start int, token, format string, args ...interface{}) error {
+	return &AlbumQueryError{Column: start + 1, Token: token, Message: fmt.Sprintf(format, args...)}
+}
+
+// term reads [-][field:]value[,value...]
+func (p *queryParser) term() (queryTerm, error) {
+	var term queryTerm
+	start := p.pos
+
+	if p.input[p.pos] == '-' {
+		term.negate = true
+		p.pos++
+		if p.done() || unicode.IsSpace(p.input[p.pos]) {
+			return term, p.fail(start, "-", "- must be followed by a term to exclude")
+		}
+	}
+
+	// A field is a run of letters followed by a colon; anything else is free text
+	fieldStart := p.pos
+	end := fieldStart
+	for end < len(p.input) && (unicode.IsLetter(p.input[end]) || p.input[end] == '_') {
+		end++
+	}
+	if end == fieldStart || end == len(p.input) || p.input[end] != ':' {
+		valueStart := p.pos
+		value, _, err := p.value(false)
+		if err != nil {
+			return term, err
+		}
+		if value == "" {
+			return term, p.fail(valueStart, string(p.input[valueStart:p.pos]), "empty quoted value")
+		}
+		if err := p.endOfValue(false); err != nil {
+			return term, err
+		}
+		term.conditions = append(term.conditions, freeText(value))
+		return term, nil
+	}
+
+	name := string(p.input[fieldStart:end])
+	field, ok := queryFields[strings.ToLower(name)]
+	if !ok {
+		return term, p.fail(fieldStart, name, "unknown field %q, expected one of %s", name, queryFieldNames())
+	}
+	p.pos = end + 1
+	if p.done() || unicode.IsSpace(p.input[p.pos]) {
+		return term, p.fail(fieldStart, name+":", "%s needs a value", strings.ToLower(name))
+	}
+
+	for {
+		valueStart := p.pos
+		value, quoted, err := p.value(true)
+		if err != nil {
+			return term, err
+		}
+		if value == "" {
+			switch {
+			case quoted:
+				return term, p.fail(valueStart, string(p.input[valueStart:p.pos]), "%s needs a value", strings.ToLower(name))
+			case valueStart > end+1:
+				return term, p.fail(valueStart-1, ",", "%s needs a value after the comma", strings.ToLower(name))
+			default:
+				return term, p.fail(fieldStart, name+":", "%s needs a value", strings.ToLower(name))
+			}
+		}
+		condition, err := field(value)
+		if err != nil {
+			return term, p.fail(valueStart, string(p.input[valueStart:p.pos]), "%s", err)
+		}
+		term.conditions = append(term.conditions, condition)
+
+		if err := p.endOfValue(true); err != nil {
+			return term, err
+		}
+		if p.done() || p.input[p.pos] != ',' {
+			return term, nil
+		}
+		p.pos++
+	}
+}
+
+// endOfValue checks that a value is followed by a space, the end of the query or, in lists, a comma
+func (p *queryParser) endOfValue(list bool) error {
+	if p.done() || unicode.IsSpace(p.input[p.pos]) || (list && p.input[p.pos] == ',') {
+		return nil
+	}
+	return p.fail(p.pos, string(p.input[p.pos]), "expected a space after the quoted value")
+}
+
+// value reads a quoted or bare value. Bare values end at a space or, in lists, a comma.
+func (p *queryParser) value(list bool) (string, bool, error) {
+	start := p.pos
+	if !p.done() && p.input[p.pos] == '"' {
+		var value strings.Builder
+		p.pos++
+		for !p.done() {
+			r := p.input[p.pos]
+			p.pos++
+			switch {
+			case r == '"':
+				return value.String(), true, nil
+			case r == '\\' && !p.done():
+				value.WriteRune(p.input[p.pos])
+				p.pos++
+			default:
+				value.WriteRune(r)
+			}
+		}
+		return "", true, p.fail(start, string(p.input[start:]), "unterminated quoted value")
+	}
+
+	for !p.done() && !unicode.IsSpace(p.input[p.pos]) && !(list && p.input[p.pos] == ',') {
+		if p.input[p.pos] == '"' {
+			return "", false, p.fail(p.pos, `"`, "quotes must surround the whole value")
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos]), false, nil
+}
+
+// likePattern escapes the LIKE wildcards in s and matches it anywhere in a string
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// textField matches values anywhere in a text column, ignoring case
+func textField(column string, get func(*models.Album) string) queryField {
+	return func(value string) (queryCondition, error) {
+		needle := strings.ToLower(value)
+		return queryCondition{
+			where: func(arg func(interface{}) string) string {
+				return fmt.Sprintf(`LOWER(%s) LIKE %s ESCAPE '\'`, column, arg(likePattern(needle)))
+			},
+			match: func(album *models.Album) bool {
+				return strings.Contains(strings.ToLower(get(album)), needle)
+			},
+		}, nil
+	}
+}
+
+// freeText matches a word without a field against the title, artist, genre and notes
+func freeText(value string) queryCondition {
+	needle := strings.ToLower(value)
+	return queryCondition{
+		where: func(arg func(interface{}) string) string {
+			placeholder := arg(likePattern(needle))
+			columns := []string{"a.title", "ar.name", "g.name", "COALESCE(a.notes, '')"}
+			for i, column := range columns {
+				columns[i] = fmt.Sprintf(`LOWER(%s) LIKE %s ESCAPE '\'`, column, placeholder)
+			}
+			return strings.Join(columns, " OR ")
+		},
+		match: func(album *models.Album) bool {
+			for _, text := range []string{album.Title, album.Artist.Name, album.Genre.Name, album.Notes} {
+				if strings.Contains(strings.ToLower(text), needle) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// parseRange reads a number (4), a range (3..5, 3.. or ..5) or a comparison (>=4, <5)
+// into inclusive bounds; a missing bound is reported as false
+func parseRange(value string, parse func(string) (int, error)) (low, high int, hasLow, hasHigh bool, err error) {
+	comparisons := []struct {
+		op     string
+		adjust int
+		low    bool
+	}{
+		{">=", 0, true}, {"<=", 0, false}, {">", 1, true}, {"<", -1, false},
+	}
+	for _, comparison := range comparisons {
+		if rest, ok := strings.CutPrefix(value, comparison.op); ok {
+			n, err := parse(rest)
+			if err != nil {
+				return 0, 0, false, false, err
+			}
+			if comparison.low {
+				return n + comparison.adjust, 0, true, false, nil
+			}
+			return 0, n + comparison.adjust, false, true, nil
+		}
+	}
+
+	from, to, isRange := strings.Cut(value, "..")
+	if !isRange {
+		n, err := parse(value)
+		return n, n, err == nil, err == nil, err
+	}
+	if from == "" && to == "" {
+		return 0, 0, false, false, fmt.Errorf("a range needs at least one bound")
+	}
+	if from != "" {
+		if low, err = parse(from); err != nil {
+			return 0, 0, false, false, err
+		}
+		hasLow = true
+	}
+	if to != "" {
+		if high, err = parse(to); err != nil {
+			return 0, 0, false, false, err
+		}
+		hasHigh = true
+	}
+	if hasLow && hasHigh && low > high {
+		return 0, 0, false, false, fmt.Errorf("the range %s is empty", value)
+	}
+	return low, high, hasLow, hasHigh, nil
+}
+
+// yearField matches release years; release_year is stored as text, so years are
+// compared as zero-padded strings like AlbumFilter does
+func yearField(value string) (queryCondition, error) {
+	low, high, hasLow, hasHigh, err := parseRange(value, func(s string) (int, error) {
+		year, err := strconv.Atoi(s)
+		if err != nil || year < 0 || year > 9999 {
+			return 0, fmt.Errorf("year must be a year like 1973, a range like 1970..1979 or a comparison like >=1970")
+		}
+		return year, nil
+	})
+	if err != nil {
+		return queryCondition{}, err
+	}
+	from, to := fmt.Sprintf("%04d", low), fmt.Sprintf("%04d", high)
+
+	return queryCondition{
+		where: func(arg func(interface{}) string) string {
+			var clauses []string
+			if hasLow && hasHigh && low == high {
+				return "a.release_year = " + arg(from)
+			}
+			if hasLow {
+				clauses = append(clauses, "a.release_year >= "+arg(from))
+			}
+			if hasHigh {
+				clauses = append(clauses, "a.release_year <= "+arg(to))
+			}
+			return strings.Join(clauses, " AND ")
+		},
+		match: func(album *models.Album) bool {
+			return (!hasLow || album.ReleaseYear >= from) && (!hasHigh || album.ReleaseYear <= to)
+		},
+	}, nil
+}
+
+// ratingField matches ratings from 1 to 5
+func ratingField(value string) (queryCondition, error) {
+	low, high, hasLow, hasHigh, err := parseRange(value, func(s string) (int, error) {
+		rating, err := strconv.Atoi(s)
+		if err != nil || rating < 1 || rating > 5 {
+			return 0, fmt.Errorf("rating must be a number from 1 to 5, a range like 3..5 or a comparison like >=4")
+		}
+		return rating, nil
+	})
+	if err != nil {
+		return queryCondition{}, err
+	}
+
+	return queryCondition{
+		where: func(arg func(interface{}) string) string {
+			var clauses []string
+			if hasLow && hasHigh && low == high {
+				return "a.rating = " + arg(low)
+			}
+			if hasLow {
+				clauses = append(clauses, "a.rating >= "+arg(low))
+			}
+			if hasHigh {
+				clauses = append(clauses, "a.rating <= "+arg(high))
+			}
+			return strings.Join(clauses, " AND ")
+		},
+		match: func(album *models.Album) bool {
+			return (!hasLow || album.Rating >= low) && (!hasHigh || album.Rating <= high)
+		},
+	}, nil
+}
+
+// conditionField matches a condition or, with a comparison, every condition better (>)
+// or worse (<) than it
+func conditionField(value string) (queryCondition, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(value, prefix); ok {
+			op, value = prefix, rest
+			break
+		}
+	}
+
+	name := strings.NewReplacer("-", " ", "_", " ").Replace(strings.ToLower(value))
+	rank := slices.IndexFunc(models.AlbumConditions, func(condition models.AlbumCondition) bool {
+		return strings.ToLower(string(condition)) == name
+	})
+	if rank < 0 {
+		names := make([]string, len(models.AlbumConditions))
+		for i, condition := range models.AlbumConditions {
+			names[i] = strings.ReplaceAll(strings.ToLower(string(condition)), " ", "-")
+		}
+		return queryCondition{}, fmt.Errorf("unknown condition %q, expected one of %s", value, strings.Join(names, ", "))
+	}
+
+	// AlbumConditions runs from best to worst, so better conditions come first
+	var conditions []models.AlbumCondition
+	switch op {
+	case ">=":
+		conditions = models.AlbumConditions[:rank+1]
+	case ">":
+		conditions = models.AlbumConditions[:rank]
+	case "<=":
+		conditions = models.AlbumConditions[rank:]
+	case "<":
+		conditions = models.AlbumConditions[rank+1:]
+	default:
+		conditions = models.AlbumConditions[rank : rank+1]
+	}
+	if len(conditions) == 0 {
+		return queryCondition{}, fmt.Errorf("no condition is %s %s", map[string]string{">": "better than", "<": "worse than"}[op], models.AlbumConditions[rank])
+	}
+
+	return queryCondition{
+		where: func(arg func(interface{}) string) string {
+			placeholders := make([]string, len(conditions))
+			for i, condition := range conditions {
+				placeholders[i] = arg(condition)
+			}
+			return "a.condition IN (" + strings.Join(placeholders, ", ") + ")"
+		},
+		match: func(album *models.Album) bool {
+			return slices.Contains(conditions, album.Condition)
+		},
+	}, nil
+}
@@ -0,0 +1,474 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+const (
+	// DefaultSearchLimit is the number of search results returned when the client doesn't ask for a page size
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the number of search results a client can request
+	MaxSearchLimit = 100
+	// MaxSearchLength caps the length of the search text, in characters
+	MaxSearchLength = 200
+)
+
+// Kinds of search results
+const (
+	SearchTypeAlbum  = "album"
+	SearchTypeArtist = "artist"
+	SearchTypeGenre  = "genre"
+)
+
+// searchTypes lists the result types in the order they're searched
+var searchTypes = []string{SearchTypeAlbum, SearchTypeArtist, SearchTypeGenre}
+
+// SearchQuery describes a full-text search and which page of results to return.
+// Text uses the web search syntax of Postgres: words are ANDed, "quoted phrases" are kept together
+// and -word excludes a word. Empty Types searches albums, artists and genres.
+type SearchQuery struct {
+	Text   string
+	Types  []string
+	Limit  int
+	Offset int
+}
+
+// withDefaults trims the text and fills in the default page size and result types
+func (q SearchQuery) withDefaults() SearchQuery {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Limit == 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	if len(q.Types) == 0 {
+		q.Types = searchTypes
+	}
+	return q
+}
+
+// Validate reports the first invalid option in the query
+func (q SearchQuery) Validate() error {
+	q = q.withDefaults()
+
+	if q.Text == "" {
+		return fmt.Errorf("q must not be empty")
+	}
+	if utf8.RuneCountInString(q.Text) > MaxSearchLength {
+		return fmt.Errorf("q must be at most %d characters", MaxSearchLength)
+	}
+	if q.Limit < 1 || q.Limit > MaxSearchLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxSearchLimit)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	for _, kind := range q.Types {
+		if !slices.Contains(searchTypes, kind) {
+			return fmt.Errorf("type must be one of %s", strings.Join(searchTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// searches reports whether results of the given type were asked for
+func (q SearchQuery) searches(kind string) bool {
+	return slices.Contains(q.Types, kind)
+}
+
+// searchHref returns the path of the resource behind a search result
+func searchHref(kind, id string) string {
+	return "/" + kind + "s/" + id
+}
+
+// newSearchPage wraps a page of search results with its pagination metadata
+func newSearchPage(results []models.SearchResult, total int, query SearchQuery) *models.SearchPage {
+	page := &models.SearchPage{
+		Items:  results,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}
+	if next := query.Offset + len(results); next < total {
+		page.NextOffset = &next
+	}
+	return page
+}
+
+// searchHeadlineOptions makes ts_headline wrap matches in <mark> and keep snippets short
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10"
+
+// searchBranches select the matches of each result type, with $1 as the search text.
+// Rows match their tsvector column or, to forgive typos, the trigram word similarity
+// of their name against the text. The document column is the text snippets are cut from.
+var searchBranches = []struct {
+	kind  string
+	query string
+}{
+	{SearchTypeAlbum, `
+				SELECT 'album' AS type, a.id, a.title AS name,
+					concat_ws(' · ', a.title, ar.name, g.name, nullif(a.notes, '')) AS document,
+					ts_rank(a.search, query) + word_similarity($1, a.title) AS score
+				FROM albums a
+				JOIN artists ar ON a.artist_id = ar.id
+				JOIN genres g ON a.genre_id = g.id
+				CROSS JOIN websearch_to_tsquery('english', $1) query
+				WHERE a.search @@ query OR $1 <% a.title`},
+	{SearchTypeArtist, `
+				SELECT 'artist' AS type, ar.id, ar.name, ar.name AS document,
+					ts_rank(ar.search, query) + word_similarity($1, ar.name) AS score
+				FROM artists ar
+				CROSS JOIN websearch_to_tsquery('english', $1) query
+				WHERE ar.search @@ query OR $1 <% ar.name`},
+	{SearchTypeGenre, `
+				SELECT 'genre' AS type, g.id, g.name, g.name AS document,
+					ts_rank(g.search, query) + word_similarity($1, g.name) AS score
+				FROM genres g
+				CROSS JOIN websearch_to_tsquery('english', $1) query
+				WHERE g.search @@ query OR $1 <% g.name`},
+}
+
+// resultsClause returns the union of the branches of the requested result types
+func (q SearchQuery) resultsClause() string {
+	var branches []string
+	for _, branch := range searchBranches {
+		if q.searches(branch.kind) {
+			branches = append(branches, branch.query)
+		}
+	}
+	return "(" + strings.Join(branches, "\n\t\t\t\tUNION ALL") + "\n\t\t\t) results"
+}
+
+// pageQuery returns the SQL selecting a page of results, with $2 and $3 as the limit and offset.
+// Headlines are expensive, so only the rows of the page get one. The document is HTML-escaped
+// first, which leaves the <mark> tags as the only markup in the snippet.
+func (q SearchQuery) pageQuery() string {
+	return `
+		SELECT type, id, name,
+			ts_headline('english', replace(replace(replace(document, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+				websearch_to_tsquery('english', $1), '` + searchHeadlineOptions + `') AS snippet,
+			score::float8
+		FROM (
+			SELECT * FROM ` + q.resultsClause() + `
+			ORDER BY score DESC, type, id
+			LIMIT $2 OFFSET $3
+		) page
+		ORDER BY score DESC, type, id`
+}
+
+// Search weights of the parts of a document, matching the defaults of ts_rank for
+// the A (title or name), B (artist), C (genre) and D (notes) labels of the Postgres schema
+const (
+	searchWeightA = 1.0
+	searchWeightB = 0.4
+	searchWeightC = 0.2
+	searchWeightD = 0.1
+)
+
+// wordSimilarityThreshold is the default pg_trgm.word_similarity_threshold
+const wordSimilarityThreshold = 0.6
+
+// searchStopWords are left out of queries, like the english text search configuration does
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "in": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+}
+
+// searchField is one weighted part of a searchDocument
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// searchDocument is an album, artist or genre as seen by searchStore
+type searchDocument struct {
+	kind   string
+	id     string
+	name   string
+	fields []searchField
+}
+
+// searchTerms splits search text into the words every match must contain and the words none may
+func searchTerms(text string) (include, exclude []string) {
+	for _, field := range strings.Fields(text) {
+		words := searchWords(field)
+		if strings.HasPrefix(field, "-") {
+			exclude = append(exclude, words...)
+			continue
+		}
+		for _, word := range words {
+			if !searchStopWords[word] {
+				include = append(include, word)
+			}
+		}
+	}
+	return include, exclude
+}
+
+// searchWords splits text into lower case words of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// hasWordPrefix reports whether one of words starts with term
+func hasWordPrefix(words []string, term string) bool {
+	return slices.ContainsFunc(words, func(word string) bool {
+		return strings.HasPrefix(word, term)
+	})
+}
+
+// match scores the document against the search text, reporting false when it doesn't match
+func (d searchDocument) match(text string, include, exclude []string) (models.SearchResult, bool) {
+	var words []string
+	for _, field := range d.fields {
+		words = append(words, searchWords(field.text)...)
+	}
+
+	// Every included word must start a word of the document, ranked by the best field it's found in
+	var rank float64
+	matched := len(include) > 0
+	for _, term := range include {
+		var best float64
+		for _, field := range d.fields {
+			if field.weight > best && hasWordPrefix(searchWords(field.text), term) {
+				best = field.weight
+			}
+		}
+		if best == 0 {
+			matched = false
+			break
+		}
+		rank += best
+	}
+	for _, term := range exclude {
+		if hasWordPrefix(words, term) {
+			matched = false
+		}
+	}
+
+	similarity := wordSimilarity(text, d.name)
+	if !matched && similarity < wordSimilarityThreshold {
+		return models.SearchResult{}, false
+	}
+
+	var highlight []string
+	if matched {
+		rank /= float64(len(include))
+		highlight = include
+	} else {
+		rank = 0
+	}
+
+	var parts []string
+	for _, field := range d.fields {
+		if field.text != "" {
+			parts = append(parts, field.text)
+		}
+	}
+
+	return models.SearchResult{
+		Type:    d.kind,
+		ID:      d.id,
+		Name:    d.name,
+		Snippet: searchSnippet(strings.Join(parts, " · "), highlight),
+		Score:   rank + similarity,
+		Href:    searchHref(d.kind, d.id),
+	}, true
+}
+
+// searchSnippetWords caps the number of words in a snippet
+const searchSnippetWords = 30
+
+// searchSnippet HTML-escapes document and wraps the words starting with a term in <mark>.
+// Long documents are cut to a window starting a few words before the first match.
+func searchSnippet(document string, terms []string) string {
+	type token struct {
+		text string
+		word bool
+	}
+	var tokens []token
+	for len(document) > 0 {
+		r, _ := utf8.DecodeRuneInString(document)
+		word := unicode.IsLetter(r) || unicode.IsNumber(r)
+		end := strings.IndexFunc(document, func(r rune) bool {
+			return (unicode.IsLetter(r) || unicode.IsNumber(r)) != word
+		})
+		if end < 0 {
+			end = len(document)
+		}
+		tokens = append(tokens, token{text: document[:end], word: word})
+		document = document[end:]
+	}
+
+	hit := func(t token) bool {
+		return t.word && slices.ContainsFunc(terms, func(term string) bool {
+			return strings.HasPrefix(strings.ToLower(t.text), term)
+		})
+	}
+
+	// Pick the window of words to show
+	first, words := 0, 0
+	for i, t := range tokens {
+		if hit(t) {
+			first = i
+			break
+		}
+	}
+	start := 0
+	for i := first; i >= 0; i-- {
+		if tokens[i].word {
+			if words == 5 {
+				break
+			}
+			words++
+			start = i
+		}
+	}
+
+	var snippet strings.Builder
+	words = 0
+	for _, t := range tokens[start:] {
+		if t.word {
+			if words == searchSnippetWords {
+				break
+			}
+			words++
+		}
+		if hit(t) {
+			snippet.WriteString("<mark>" + html.EscapeString(t.text) + "</mark>")
+		} else {
+			snippet.WriteString(html.EscapeString(t.text))
+		}
+	}
+	return strings.TrimSpace(snippet.String())
+}
+
+// trigrams returns the pg_trgm trigrams of words: each word is padded with two spaces in front and one behind
+func trigrams(words []string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// wordSimilarity approximates pg_trgm's word_similarity: the best trigram similarity between
+// the query and a run of consecutive words of the text
+func wordSimilarity(query, text string) float64 {
+	want := trigrams(searchWords(query))
+	if len(want) == 0 {
+		return 0
+	}
+
+	words := searchWords(text)
+	var best float64
+	for i := range words {
+		for j := i + 1; j <= len(words); j++ {
+			extent := trigrams(words[i:j])
+			shared := 0
+			for trigram := range want {
+				if extent[trigram] {
+					shared++
+				}
+			}
+			best = max(best, float64(shared)/float64(len(want)+len(extent)-shared))
+		}
+	}
+	return best
+}
+
+// searchStore searches the collection through the read methods of a store without a
+// full-text index. It approximates the Postgres search: included words must start a word of
+// the album, artist or genre, and names within the trigram similarity threshold match too.
+func searchStore(ctx context.Context, store Store, query SearchQuery) (*models.SearchPage, error) {
+	query = query.withDefaults()
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	var documents []searchDocument
+	if query.searches(SearchTypeAlbum) {
+		filter := AlbumFilter{Limit: MaxAlbumLimit}
+		for {
+			page, err := store.GetAlbums(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			for _, album := range page.Items {
+				documents = append(documents, searchDocument{
+					kind: SearchTypeAlbum,
+					id:   album.ID,
+					name: album.Title,
+					fields: []searchField{
+						{album.Title, searchWeightA},
+						{album.Artist.Name, searchWeightB},
+						{album.Genre.Name, searchWeightC},
+						{album.Notes, searchWeightD},
+					},
+				})
+			}
+			if page.NextOffset == nil {
+				break
+			}
+			filter.Offset = *page.NextOffset
+		}
+	}
+	if query.searches(SearchTypeArtist) {
+		artists, err := store.GetArtists(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, artist := range artists {
+			documents = append(documents, searchDocument{
+				kind:   SearchTypeArtist,
+				id:     artist.ID,
+				name:   artist.Name,
+				fields: []searchField{{artist.Name, searchWeightA}},
+			})
+		}
+	}
+	if query.searches(SearchTypeGenre) {
+		genres, err := store.GetGenres(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, genre := range genres {
+			documents = append(documents, searchDocument{
+				kind:   SearchTypeGenre,
+				id:     genre.ID,
+				name:   genre.Name,
+				fields: []searchField{{genre.Name, searchWeightA}},
+			})
+		}
+	}
+
+	include, exclude := searchTerms(query.Text)
+	var matches []models.SearchResult
+	for _, document := range documents {
+		if result, ok := document.match(query.Text, include, exclude); ok {
+			matches = append(matches, result)
+		}
+	}
+	slices.SortFunc(matches, func(a, b models.SearchResult) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Type, b.Type), cmp.Compare(a.ID, b.ID))
+	})
+
+	results := []models.SearchResult{}
+	if query.Offset < len(matches) {
+		end := min(query.Offset+query.Limit, len(matches))
+		results = append(results, matches[query.Offset:end]...)
+	}
+
+	return newSearchPage(results, len(matches), query), nil
+}
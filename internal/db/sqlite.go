@@ -0,0 +1,591 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+
+	// Registers the pure Go "sqlite" driver, so the binary still builds with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is the SQLite implementation of Store, meant for single-user installs
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at path, creating an empty file if needed.
+// Use its Migrator to create the schema.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection avoids "database is locked" errors
+	conn.SetMaxOpenConns(1)
+
+	slog.Info("Opened SQLite database", "path", path)
+	return &SQLiteStore{db: conn}, nil
+}
+
+// Migrator returns the runner for the SQLite migrations
+func (s *SQLiteStore) Migrator() (*Migrator, error) {
+	return newMigrator(sqliteMigrationTarget{db: s.db}, sqliteMigrations, "migrations/sqlite")
+}
+
+// Ping checks that the database file can be opened
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// PoolStats reports the state of the connection pool
+func (s *SQLiteStore) PoolStats() (PoolStats, bool) {
+	stat := s.db.Stats()
+	return PoolStats{
+		MaxConns:      stat.MaxOpenConnections,
+		TotalConns:    stat.OpenConnections,
+		IdleConns:     stat.Idle,
+		AcquiredConns: stat.InUse,
+		WaitCount:     stat.WaitCount,
+		WaitDuration:  stat.WaitDuration,
+	}, true
+}
+
+// Close closes the underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// albumReferenceError works out which reference of an album write was rejected.
+// SQLite doesn't name the foreign key that failed, so the artist and genre are looked up.
+func (s *SQLiteStore) albumReferenceError(ctx context.Context, err error, artistID, genreID *string) error {
+	if !errors.Is(err, ErrInvalidReference) {
+		return err
+	}
+
+	references := []struct {
+		id         *string
+		table      string
+		constraint string
+	}{
+		{artistID, "artists", "albums_artist_id_fkey"},
+		{genreID, "genres", "albums_genre_id_fkey"},
+	}
+	for _, ref := range references {
+		if ref.id == nil {
+			continue
+		}
+		var found bool
+		if lookupErr := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+ref.table+" WHERE id = $1)", *ref.id).Scan(&found); lookupErr != nil {
+			return err
+		}
+		if !found {
+			return violation(ErrInvalidReference, ref.constraint)
+		}
+	}
+	return err
+}
+
+// GetAlbums retrieves a page of albums matching the filter
+func (s *SQLiteStore) GetAlbums(ctx context.Context, filter AlbumFilter) (*models.AlbumPage, error) {
+	filter = filter.withDefaults()
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	where, args := filter.whereClause()
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*)"+albumJoins+where, args...).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := "SELECT " + albumColumns + albumJoins + where + filter.orderClause() +
+		fmt.Sprintf("\n\t\tLIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newAlbumPage(albums, total, filter), nil
+}
+
+// CountAlbums counts the albums in the database
+func (s *SQLiteStore) CountAlbums(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM albums").Scan(&count)
+	return count, err
+}
+
+// GetAlbumByID retrieves a single album by ID
+func (s *SQLiteStore) GetAlbumByID(ctx context.Context, id string) (*models.Album, error) {
+	album, err := scanAlbum(s.db.QueryRowContext(ctx, "SELECT "+albumColumns+albumJoins+"\n\t\tWHERE a.id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // No album found
+		}
+		return nil, err
+	}
+
+	return album, nil
+}
+
+// CreateAlbum adds a new album to the database
+func (s *SQLiteStore) CreateAlbum(ctx context.Context, album models.Album) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO albums (id, title, artist_id, release_year, genre_id, notes, rating, condition)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, album.ID, album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition)
+
+	return s.albumReferenceError(ctx, translateSQLiteError(err), &album.ArtistID, &album.GenreID)
+}
+
+// UpdateAlbum updates an existing album
+func (s *SQLiteStore) UpdateAlbum(ctx context.Context, album models.Album) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE albums
+		SET title = $2, artist_id = $3, release_year = $4, genre_id = $5, notes = $6, rating = $7, condition = $8
+		WHERE id = $1
+	`, album.ID, album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition)
+	if err != nil {
+		return s.albumReferenceError(ctx, translateSQLiteError(err), &album.ArtistID, &album.GenreID)
+	}
+	return checkRowsAffected(result)
+}
+
+// PatchAlbum updates only the columns supplied in the patch, leaving the others untouched.
+// An empty patch is a no-op.
+func (s *SQLiteStore) PatchAlbum(ctx context.Context, id string, patch, expected models.AlbumPatch) error {
+	statement, args := patchAlbumStatement(id, patch, expected)
+	if statement == "" {
+		return nil
+	}
+
+	result, err := s.db.ExecContext(ctx, statement, args...)
+	if err != nil {
+		return s.albumReferenceError(ctx, translateSQLiteError(err), patch.ArtistID, patch.GenreID)
+	}
+	err = checkRowsAffected(result)
+	if !errors.Is(err, ErrNotFound) || expected.IsEmpty() {
+		return err
+	}
+
+	// The album is either gone or no longer has the expected values
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM albums WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrModified
+	}
+	return ErrNotFound
+}
+
+// DeleteAlbum removes an album from the database
+func (s *SQLiteStore) DeleteAlbum(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM albums WHERE id = $1", id)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// GetArtists retrieves all artists from the database
+func (s *SQLiteStore) GetArtists(ctx context.Context) ([]models.Artist, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name FROM artists ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	artists := []models.Artist{}
+	for rows.Next() {
+		var artist models.Artist
+		err = rows.Scan(&artist.ID, &artist.Name)
+		if err != nil {
+			return nil, err
+		}
+		artists = append(artists, artist)
+	}
+
+	return artists, rows.Err()
+}
+
+// CountArtists counts the artists in the database
+func (s *SQLiteStore) CountArtists(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM artists").Scan(&count)
+	return count, err
+}
+
+// GetArtistByID retrieves a single artist by ID
+func (s *SQLiteStore) GetArtistByID(ctx context.Context, id string) (*models.Artist, error) {
+	var artist models.Artist
+	err := s.db.QueryRowContext(ctx, "SELECT id, name FROM artists WHERE id = $1", id).
+		Scan(&artist.ID, &artist.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // No artist found
+		}
+		return nil, err
+	}
+
+	return &artist, nil
+}
+
+// CreateArtist adds a new artist to the database
+func (s *SQLiteStore) CreateArtist(ctx context.Context, artist models.Artist) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO artists (id, name) VALUES ($1, $2)", artist.ID, artist.Name)
+	return translateSQLiteError(err)
+}
+
+// UpdateArtist updates an existing artist
+func (s *SQLiteStore) UpdateArtist(ctx context.Context, artist models.Artist) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE artists SET name = $2 WHERE id = $1", artist.ID, artist.Name)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// PatchArtist updates only the fields supplied in the patch
+func (s *SQLiteStore) PatchArtist(ctx context.Context, id string, patch models.ArtistPatch) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE artists SET name = COALESCE($2, name) WHERE id = $1", id, patch.Name)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// DeleteArtist removes an artist from the database.
+// Artists that still have albums are never deleted and ErrConflict is returned instead.
+func (s *SQLiteStore) DeleteArtist(ctx context.Context, id string) error {
+	var albums int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM albums WHERE artist_id = $1", id).Scan(&albums)
+	if err != nil {
+		return err
+	}
+	if albums > 0 {
+		return fmt.Errorf("%w: artist %s still has %d album(s)", ErrConflict, id, albums)
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM artists WHERE id = $1", id)
+	if err != nil {
+		return deleteConflict("artist", id, translateSQLiteError(err))
+	}
+	return checkRowsAffected(result)
+}
+
+// GetGenres retrieves all genres from the database
+func (s *SQLiteStore) GetGenres(ctx context.Context) ([]models.Genre, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, icon FROM genres ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []models.Genre{}
+	for rows.Next() {
+		var genre models.Genre
+		err = rows.Scan(&genre.ID, &genre.Name, &genre.Icon)
+		if err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, rows.Err()
+}
+
+// CountGenres counts the genres in the database
+func (s *SQLiteStore) CountGenres(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM genres").Scan(&count)
+	return count, err
+}
+
+// GetGenreByID retrieves a single genre by ID
+func (s *SQLiteStore) GetGenreByID(ctx context.Context, id string) (*models.Genre, error) {
+	var genre models.Genre
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, icon FROM genres WHERE id = $1", id).
+		Scan(&genre.ID, &genre.Name, &genre.Icon)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // No genre found
+		}
+		return nil, err
+	}
+
+	return &genre, nil
+}
+
+// CreateGenre adds a new genre to the database
+func (s *SQLiteStore) CreateGenre(ctx context.Context, genre models.Genre) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO genres (id, name, icon) VALUES ($1, $2, $3)", genre.ID, genre.Name, genre.Icon)
+	return translateSQLiteError(err)
+}
+
+// UpdateGenre updates an existing genre, including its icon
+func (s *SQLiteStore) UpdateGenre(ctx context.Context, genre models.Genre) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE genres SET name = $2, icon = $3 WHERE id = $1", genre.ID, genre.Name, genre.Icon)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// PatchGenre updates only the fields supplied in the patch
+func (s *SQLiteStore) PatchGenre(ctx context.Context, id string, patch models.GenrePatch) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE genres SET name = COALESCE($2, name), icon = COALESCE($3, icon) WHERE id = $1", id, patch.Name, patch.Icon)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// DeleteGenre removes a genre from the database.
+// Like artists, genres that still have albums are never deleted and ErrConflict is returned instead.
+func (s *SQLiteStore) DeleteGenre(ctx context.Context, id string) error {
+	var albums int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM albums WHERE genre_id = $1", id).Scan(&albums)
+	if err != nil {
+		return err
+	}
+	if albums > 0 {
+		return fmt.Errorf("%w: genre %s still has %d album(s)", ErrConflict, id, albums)
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM genres WHERE id = $1", id)
+	if err != nil {
+		return deleteConflict("genre", id, translateSQLiteError(err))
+	}
+	return checkRowsAffected(result)
+}
+
+// GetCollections retrieves all collections, ordered by name
+func (s *SQLiteStore) GetCollections(ctx context.Context) ([]models.Collection, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+collectionColumns+" FROM collections ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// The single connection must be free for the next query
+	rows.Close()
+
+	// Attach the albums of the manual collections
+	rows, err = s.db.QueryContext(ctx, "SELECT collection_id, album_id FROM collection_albums ORDER BY collection_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albumIDs := map[string][]string{}
+	for rows.Next() {
+		var collectionID, albumID string
+		if err := rows.Scan(&collectionID, &albumID); err != nil {
+			return nil, err
+		}
+		albumIDs[collectionID] = append(albumIDs[collectionID], albumID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range collections {
+		collections[i].AlbumIDs = albumIDs[collections[i].ID]
+	}
+	return collections, nil
+}
+
+// GetCollectionByID retrieves a single collection by ID, with the albums of a manual collection in order
+func (s *SQLiteStore) GetCollectionByID(ctx context.Context, id string) (*models.Collection, error) {
+	collection, err := scanCollection(s.db.QueryRowContext(ctx, "SELECT "+collectionColumns+" FROM collections WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // No collection found
+		}
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT album_id FROM collection_albums WHERE collection_id = $1 ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var albumID string
+		if err := rows.Scan(&albumID); err != nil {
+			return nil, err
+		}
+		collection.AlbumIDs = append(collection.AlbumIDs, albumID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collection, nil
+}
+
+// CreateCollection adds a new collection and its albums in a single transaction
+func (s *SQLiteStore) CreateCollection(ctx context.Context, collection models.Collection) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO collections (id, name, kind, query, sort, sort_order) VALUES ($1, $2, $3, $4, $5, $6)",
+		collection.ID, collection.Name, collection.Kind, collection.Query, collection.Sort, collection.Order)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	if err := insertSQLiteCollectionAlbums(ctx, tx, collection); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateCollection replaces a collection and its albums in a single transaction
+func (s *SQLiteStore) UpdateCollection(ctx context.Context, collection models.Collection) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE collections SET name = $2, kind = $3, query = $4, sort = $5, sort_order = $6 WHERE id = $1",
+		collection.ID, collection.Name, collection.Kind, collection.Query, collection.Sort, collection.Order)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	if err := checkRowsAffected(result); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM collection_albums WHERE collection_id = $1", collection.ID); err != nil {
+		return err
+	}
+	if err := insertSQLiteCollectionAlbums(ctx, tx, collection); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertSQLiteCollectionAlbums stores the albums of a collection, numbered in order.
+// SQLite doesn't say which constraint failed, but the collection was just written,
+// so a violation is always about the album IDs.
+func insertSQLiteCollectionAlbums(ctx context.Context, tx *sql.Tx, collection models.Collection) error {
+	for i, albumID := range collection.AlbumIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO collection_albums (collection_id, album_id, position) VALUES ($1, $2, $3)",
+			collection.ID, albumID, i+1)
+		switch err := translateSQLiteError(err); {
+		case errors.Is(err, ErrInvalidReference):
+			return violation(ErrInvalidReference, "collection_albums_album_id_fkey")
+		case errors.Is(err, ErrConflict):
+			return violation(ErrConflict, "collection_albums_pkey")
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCollection removes a collection; its albums are left alone
+func (s *SQLiteStore) DeleteCollection(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM collections WHERE id = $1", id)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+	return checkRowsAffected(result)
+}
+
+// GetCollectionAlbums retrieves a page of the albums of a manual collection matching the filter
+func (s *SQLiteStore) GetCollectionAlbums(ctx context.Context, id string, filter AlbumFilter) (*models.AlbumPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	query := newCollectionAlbumsQuery(id, filter)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, query.count, query.args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, query.page, query.pageArgs()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	albums := []models.Album{}
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, *album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return newAlbumPage(albums, total, query.filter), nil
+}
+
+// Search finds albums, artists and genres matching the query, best match first.
+// SQLite has no full-text index here, so the collection is searched in Go.
+func (s *SQLiteStore) Search(ctx context.Context, query SearchQuery) (*models.SearchPage, error) {
+	return searchStore(ctx, s, query)
+}
+
+// checkRowsAffected returns ErrNotFound when a statement didn't touch any row
+func checkRowsAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Ensure SQLiteStore implements Store
+var _ Store = (*SQLiteStore)(nil)
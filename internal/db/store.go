@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+// AlbumStore persists albums. Reads return albums joined with their artist and genre.
+type AlbumStore interface {
+	GetAlbums(ctx context.Context, filter AlbumFilter) (*models.AlbumPage, error)
+	CountAlbums(ctx context.Context) (int, error)
+	// GetAlbumByID returns nil without an error when the album doesn't exist
+	GetAlbumByID(ctx context.Context, id string) (*models.Album, error)
+	CreateAlbum(ctx context.Context, album models.Album) error
+	UpdateAlbum(ctx context.Context, album models.Album) error
+	// PatchAlbum changes only the fields supplied in the patch. The album must still have the
+	// non-nil values of expected, or the patch fails with ErrModified.
+	PatchAlbum(ctx context.Context, id string, patch, expected models.AlbumPatch) error
+	DeleteAlbum(ctx context.Context, id string) error
+}
+
+// ArtistStore persists artists
+type ArtistStore interface {
+	GetArtists(ctx context.Context) ([]models.Artist, error)
+	CountArtists(ctx context.Context) (int, error)
+	// GetArtistByID returns nil without an error when the artist doesn't exist
+	GetArtistByID(ctx context.Context, id string) (*models.Artist, error)
+	CreateArtist(ctx context.Context, artist models.Artist) error
+	UpdateArtist(ctx context.Context, artist models.Artist) error
+	// PatchArtist changes only the fields supplied in the patch, in a single update
+	PatchArtist(ctx context.Context, id string, patch models.ArtistPatch) error
+	DeleteArtist(ctx context.Context, id string) error
+}
+
+// GenreStore persists genres
+type GenreStore interface {
+	GetGenres(ctx context.Context) ([]models.Genre, error)
+	CountGenres(ctx context.Context) (int, error)
+	// GetGenreByID returns nil without an error when the genre doesn't exist
+	GetGenreByID(ctx context.Context, id string) (*models.Genre, error)
+	CreateGenre(ctx context.Context, genre models.Genre) error
+	UpdateGenre(ctx context.Context, genre models.Genre) error
+	// PatchGenre changes only the fields supplied in the patch, in a single update
+	PatchGenre(ctx context.Context, id string, patch models.GenrePatch) error
+	DeleteGenre(ctx context.Context, id string) error
+}
+
+// CollectionStore persists smart and manual collections. Reads of manual collections
+// include their album IDs in order.
+type CollectionStore interface {
+	GetCollections(ctx context.Context) ([]models.Collection, error)
+	// GetCollectionByID returns nil without an error when the collection doesn't exist
+	GetCollectionByID(ctx context.Context, id string) (*models.Collection, error)
+	CreateCollection(ctx context.Context, collection models.Collection) error
+	// UpdateCollection replaces a collection, including its albums
+	UpdateCollection(ctx context.Context, collection models.Collection) error
+	DeleteCollection(ctx context.Context, id string) error
+	// GetCollectionAlbums returns a page of the albums of a manual collection that match the filter.
+	// They keep the collection's order unless the filter has a Sort.
+	GetCollectionAlbums(ctx context.Context, id string, filter AlbumFilter) (*models.AlbumPage, error)
+}
+
+// SearchStore finds albums, artists and genres matching free text
+type SearchStore interface {
+	Search(ctx context.Context, query SearchQuery) (*models.SearchPage, error)
+}
+
+// Store is the complete data layer used by the API.
+//
+// Implementations report missing records on update and delete with ErrNotFound,
+// and constraint violations with a *ConstraintError.
+type Store interface {
+	AlbumStore
+	ArtistStore
+	GenreStore
+	CollectionStore
+	SearchStore
+
+	// Close releases the store's connections; the store must not be used afterwards
+	Close() error
+}
+
+// Pinger is implemented by stores that talk to a database server
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PoolStats describes the connections held by a store. WaitCount and WaitDuration
+// add up the acquires that had to wait for a free connection since the pool was opened.
+type PoolStats struct {
+	MaxConns      int           `json:"max_conns"`
+	TotalConns    int           `json:"total_conns"`
+	IdleConns     int           `json:"idle_conns"`
+	AcquiredConns int           `json:"acquired_conns"`
+	WaitCount     int64         `json:"wait_count"`
+	WaitDuration  time.Duration `json:"wait_duration_ns"`
+}
+
+// PoolStatter is implemented by stores that keep a connection pool.
+// PoolStats returns false when the pool has no statistics to report.
+type PoolStatter interface {
+	PoolStats() (PoolStats, bool)
+}
+
+// Ensure PostgresStore implements Store
+var _ Store = (*PostgresStore)(nil)
+
+// Ensure the SQL stores can be migrated and checked
+var (
+	_ Migratable  = (*PostgresStore)(nil)
+	_ Migratable  = (*SQLiteStore)(nil)
+	_ Pinger      = (*PostgresStore)(nil)
+	_ Pinger      = (*SQLiteStore)(nil)
+	_ PoolStatter = (*PostgresStore)(nil)
+	_ PoolStatter = (*SQLiteStore)(nil)
+)
+
+// sqliteScheme prefixes database URLs that point at a SQLite file, e.g. sqlite:///var/lib/vinylvault/vault.db
+const sqliteScheme = "sqlite://"
+
+// Open connects to the store described by a database URL. sqlite:// URLs open a SQLite file,
+// anything else is treated as a Postgres connection string and connected to with opts.
+func Open(ctx context.Context, databaseURL string, opts ConnectOptions) (Store, error) {
+	if path, ok := strings.CutPrefix(databaseURL, sqliteScheme); ok {
+		if path == "" {
+			return nil, fmt.Errorf("database URL %q has no file path", databaseURL)
+		}
+		return OpenSQLite(path)
+	}
+
+	pool, err := InitializeDB(ctx, databaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewPostgresStore(pool), nil
+}
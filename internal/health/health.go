@@ -0,0 +1,108 @@
+// Package health runs the named checks behind the readiness endpoint
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses reported for checks and for the report as a whole
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout limits each check when the checker is created without a timeout
+const DefaultTimeout = 2 * time.Second
+
+// CheckFunc verifies one dependency. It returns details worth reporting, e.g. statistics,
+// and an error if the dependency isn't usable.
+type CheckFunc func(ctx context.Context) (details interface{}, err error)
+
+// Check is a named CheckFunc
+type Check struct {
+	Name  string
+	Check CheckFunc
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    string      `json:"status" example:"ok"`
+	LatencyMs float64     `json:"latency_ms" example:"1.25"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Report is the outcome of every check; its status is ok only if every check passed
+type Report struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs a list of checks concurrently, each with its own timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []Check
+}
+
+// NewChecker creates a checker running the given checks. A timeout of 0 uses DefaultTimeout.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+// Add registers another check
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.checks = append(c.checks, Check{Name: name, Check: check})
+}
+
+// Run runs every check and collects their results
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check.Check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs a single check under the checker's timeout and measures how long it took
+func (c *Checker) run(ctx context.Context, check CheckFunc) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	result := Result{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
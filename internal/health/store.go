@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+)
+
+// schemaDetails reports the schema version next to the one the binary expects
+type schemaDetails struct {
+	Version  int `json:"version"`
+	Expected int `json:"expected"`
+}
+
+// StoreChecks returns the checks that apply to a store: a ping of its database,
+// a check that the schema is at the version this binary expects, and its pool statistics,
+// which are reported but never fail the check.
+// Stores without a database, like the memory store, have no checks.
+func StoreChecks(store db.Store) []Check {
+	var checks []Check
+
+	if pinger, ok := store.(db.Pinger); ok {
+		checks = append(checks, Check{Name: "database", Check: func(ctx context.Context) (interface{}, error) {
+			return nil, pinger.Ping(ctx)
+		}})
+	}
+
+	if migratable, ok := store.(db.Migratable); ok {
+		migrator, err := migratable.Migrator()
+		checks = append(checks, Check{Name: "schema", Check: func(ctx context.Context) (interface{}, error) {
+			if err != nil {
+				return nil, err
+			}
+			version, err := migrator.Version(ctx)
+			if err != nil {
+				return nil, err
+			}
+			details := schemaDetails{Version: version, Expected: migrator.Latest()}
+			if version != details.Expected {
+				return details, fmt.Errorf("database schema is at version %d, expected %d", version, details.Expected)
+			}
+			return details, nil
+		}})
+	}
+
+	if statter, ok := store.(db.PoolStatter); ok {
+		if _, ok := statter.PoolStats(); ok {
+			checks = append(checks, Check{Name: "pool", Check: func(ctx context.Context) (interface{}, error) {
+				stats, _ := statter.PoolStats()
+				return stats, nil
+			}})
+		}
+	}
+
+	return checks
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectionTimeout limits the store queries run while Prometheus scrapes
+const collectionTimeout = 5 * time.Second
+
+// poolCollector reads the connection pool statistics on every scrape
+type poolCollector struct {
+	statter       db.PoolStatter
+	maxConns      *prometheus.Desc
+	totalConns    *prometheus.Desc
+	idleConns     *prometheus.Desc
+	acquiredConns *prometheus.Desc
+	waitCount     *prometheus.Desc
+	waitDuration  *prometheus.Desc
+}
+
+func newPoolCollector(statter db.PoolStatter) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		statter:       statter,
+		maxConns:      desc("max_conns", "Maximum number of connections in the pool."),
+		totalConns:    desc("total_conns", "Connections currently open, idle or in use."),
+		idleConns:     desc("idle_conns", "Connections currently idle."),
+		acquiredConns: desc("acquired_conns", "Connections currently in use."),
+		waitCount:     desc("wait_count_total", "Acquires that had to wait for a free connection."),
+		waitDuration:  desc("wait_duration_seconds_total", "Time spent waiting for a free connection."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.acquiredConns
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, ok := c.statter.PoolStats()
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stats.MaxConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stats.AcquiredConns))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// collectionCollector counts the albums, artists and genres on every scrape
+type collectionCollector struct {
+	store   db.Store
+	albums  *prometheus.Desc
+	artists *prometheus.Desc
+	genres  *prometheus.Desc
+}
+
+func newCollectionCollector(store db.Store) *collectionCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+	}
+	return &collectionCollector{
+		store:   store,
+		albums:  desc("albums", "Albums in the collection."),
+		artists: desc("artists", "Artists in the collection."),
+		genres:  desc("genres", "Genres in the collection."),
+	}
+}
+
+func (c *collectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.albums
+	ch <- c.artists
+	ch <- c.genres
+}
+
+// Collect leaves out the counts it can't read, so a database outage shows up
+// as missing series rather than as an empty collection
+func (c *collectionCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectionTimeout)
+	defer cancel()
+
+	if count, err := c.store.CountAlbums(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.albums, prometheus.GaugeValue, float64(count))
+	} else {
+		slog.WarnContext(ctx, "Unable to count albums for metrics", "error", err)
+	}
+	if count, err := c.store.CountArtists(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.artists, prometheus.GaugeValue, float64(count))
+	} else {
+		slog.WarnContext(ctx, "Unable to count artists for metrics", "error", err)
+	}
+	if count, err := c.store.CountGenres(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.genres, prometheus.GaugeValue, float64(count))
+	} else {
+		slog.WarnContext(ctx, "Unable to count genres for metrics", "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package metrics exposes the server's Prometheus metrics: HTTP traffic, database queries,
+// connection pool statistics and the size of the collection
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "vinylvault"
+
+// unmatchedRoute labels requests that didn't match a registered route, so that
+// scans for random paths don't create a series per path
+const unmatchedRoute = "unmatched"
+
+// Metrics holds the collectors, registered on a registry of their own
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+}
+
+// New creates the HTTP and query metrics, along with the Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests served, by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time spent serving HTTP requests, by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Time spent on database queries, by operation and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"operation", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Registry returns the registry holding the collectors, e.g. to register more of them
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RegisterStore adds the metrics read from the store on every scrape:
+// its connection pool statistics, if it has a pool, and the size of the collection
+func (m *Metrics) RegisterStore(store db.Store) {
+	if statter, ok := store.(db.PoolStatter); ok {
+		if _, ok := statter.PoolStats(); ok {
+			m.registry.MustRegister(newPoolCollector(statter))
+		}
+	}
+	m.registry.MustRegister(newCollectionCollector(store))
+}
+
+// Middleware counts and times every request. Requests are labelled with the route
+// template, e.g. /albums/:id, rather than the path.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.requests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.requestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
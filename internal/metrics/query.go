@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// queryStartKey carries the start time of a query from TraceQueryStart to TraceQueryEnd
+type queryStartKey struct{}
+
+// queryTracer times the queries run by a pgx connection pool
+type queryTracer struct {
+	metrics *Metrics
+}
+
+// QueryTracer returns a pgx tracer recording the duration of every query,
+// for db.ConnectOptions.Tracers. Only Postgres runs its queries through pgx,
+// so the queries of the SQLite store aren't recorded.
+func (m *Metrics) QueryTracer() pgx.QueryTracer {
+	return queryTracer{metrics: m}
+}
+
+// queryStart records what TraceQueryEnd needs to label the query
+type queryStart struct {
+	operation string
+	start     time.Time
+}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{operation: db.QueryOperation(data.SQL), start: time.Now()})
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	outcome := "success"
+	if data.Err != nil {
+		outcome = "error"
+	}
+	t.metrics.queryDuration.WithLabelValues(started.operation, outcome).Observe(time.Since(started.start).Seconds())
+}
@@ -4,32 +4,125 @@ package models
 // @Description Information about a vinyl record
 type Album struct {
 	ID          string         `json:"id" example:"alb-12345678" format:"uuid"`
-	Title       string         `json:"title" example:"The Dark Side of the Moon" binding:"required"`
-	ArtistID    string         `json:"artist_id" example:"art-001"`
+	Title       string         `json:"title" example:"The Dark Side of the Moon" binding:"required,notblank"`
+	ArtistID    string         `json:"artist_id" example:"art-001" binding:"required"`
 	Artist      *Artist        `json:"artist,omitempty"`
-	ReleaseYear string         `json:"release_year" example:"1973" binding:"required"`
-	GenreID     string         `json:"genre_id" example:"gen-001"`
+	ReleaseYear string         `json:"release_year" example:"1973" binding:"required,release_year"`
+	GenreID     string         `json:"genre_id" example:"gen-001" binding:"required"`
 	Genre       *Genre         `json:"genre,omitempty"`
 	Notes       string         `json:"notes" example:"Original pressing with posters and stickers"`
-	Rating      int            `json:"rating" example:"5" minimum:"1" maximum:"5"` // 1-5 stars
-	Condition   AlbumCondition `json:"condition" example:"Excellent" enums:"Mint,Excellent,Very Good,Good,Fair,Poor"`
+	Rating      int            `json:"rating" example:"5" minimum:"1" maximum:"5" binding:"min=1,max=5"` // 1-5 stars
+	Condition   AlbumCondition `json:"condition" example:"Excellent" enums:"Mint,Excellent,Very Good,Good,Fair,Poor" binding:"album_condition"`
+}
+
+// AlbumPatch holds the album fields to change in a PATCH request; nil fields are left untouched
+// @Description Partial update of a vinyl record
+type AlbumPatch struct {
+	Title       *string         `json:"title,omitempty" example:"The Dark Side of the Moon" binding:"omitnil,notblank"`
+	ArtistID    *string         `json:"artist_id,omitempty" example:"art-001" binding:"omitnil,notblank"`
+	ReleaseYear *string         `json:"release_year,omitempty" example:"1973" binding:"omitnil,release_year"`
+	GenreID     *string         `json:"genre_id,omitempty" example:"gen-001" binding:"omitnil,notblank"`
+	Notes       *string         `json:"notes,omitempty" example:"Original pressing with posters and stickers"`
+	Rating      *int            `json:"rating,omitempty" example:"5" minimum:"1" maximum:"5" binding:"omitnil,min=1,max=5"`
+	Condition   *AlbumCondition `json:"condition,omitempty" example:"Excellent" enums:"Mint,Excellent,Very Good,Good,Fair,Poor" binding:"omitnil,album_condition"`
+}
+
+// IsEmpty reports whether the patch doesn't change anything
+func (p AlbumPatch) IsEmpty() bool {
+	return p == AlbumPatch{}
+}
+
+// AlbumPage is a single page of albums along with pagination metadata
+// @Description A page of albums
+type AlbumPage struct {
+	Items      []Album `json:"items"`
+	Total      int     `json:"total" example:"120"`                // Number of albums matching the filters
+	Limit      int     `json:"limit" example:"50"`                 // Page size
+	Offset     int     `json:"offset" example:"0"`                 // Offset of the first item in the page
+	NextOffset *int    `json:"next_offset,omitempty" example:"50"` // Offset of the next page, omitted on the last page
 }
 
 // Artist represents a musical artist
 // @Description Information about a music artist
 type Artist struct {
 	ID   string `json:"id" example:"art-001" format:"uuid"`
-	Name string `json:"name" example:"Pink Floyd" binding:"required"`
+	Name string `json:"name" example:"Pink Floyd" binding:"required,notblank"`
+}
+
+// ArtistPatch holds the artist fields to change in a PATCH request; omitted fields are left untouched
+// @Description Partial update of a music artist
+type ArtistPatch struct {
+	Name *string `json:"name" example:"Pink Floyd" binding:"omitnil,notblank"`
 }
 
 // Genre represents a music genre
 // @Description Information about a music genre
 type Genre struct {
 	ID   string `json:"id" example:"gen-001" format:"uuid"`
-	Name string `json:"name" example:"Rock" binding:"required"`
+	Name string `json:"name" example:"Rock" binding:"required,notblank"`
 	Icon string `json:"icon" example:"🎸"` // Could be an emoji
 }
 
+// GenrePatch holds the genre fields to change in a PATCH request; omitted fields are left untouched
+// @Description Partial update of a music genre
+type GenrePatch struct {
+	Name *string `json:"name" example:"Rock" binding:"omitnil,notblank"`
+	Icon *string `json:"icon" example:"🎸"` // An empty string removes the icon
+}
+
+// CollectionKind tells smart collections, defined by an album query, from manual ones
+type CollectionKind string
+
+const (
+	CollectionSmart  CollectionKind = "smart"
+	CollectionManual CollectionKind = "manual"
+)
+
+// Collection is a named group of albums. A smart collection saves an album query, so its albums
+// change along with the collection; a manual collection lists its albums in a chosen order.
+// @Description A smart or manual collection of albums
+type Collection struct {
+	ID       string         `json:"id" example:"col-001" format:"uuid"`
+	Name     string         `json:"name" example:"5-star 70s rock" binding:"required,notblank"`
+	Kind     CollectionKind `json:"kind" example:"smart" enums:"smart,manual" binding:"required,oneof=smart manual"`
+	Query    string         `json:"query,omitempty" example:"genre:rock year:1970..1979 rating:5" binding:"required_if=Kind smart,excluded_if=Kind manual,album_query"` // Album query of a smart collection
+	AlbumIDs []string       `json:"album_ids,omitempty" example:"alb-001,alb-003" binding:"excluded_if=Kind smart,unique,dive,notblank"`                                // Albums of a manual collection, in order
+	Sort     string         `json:"sort,omitempty" example:"release_year" enums:"title,artist,release_year,rating,condition" binding:"omitempty,oneof=title artist release_year rating condition"`
+	Order    string         `json:"order,omitempty" example:"asc" enums:"asc,desc" binding:"omitempty,oneof=asc desc"`
+}
+
+// CollectionPatch holds the collection fields to change in a PATCH request; omitted fields are left untouched.
+// The kind of a collection can only be changed by replacing it.
+// @Description Partial update of a collection
+type CollectionPatch struct {
+	Name     *string   `json:"name,omitempty" example:"5-star 70s rock" binding:"omitnil,notblank"`
+	Query    *string   `json:"query,omitempty" example:"genre:rock year:1970..1979 rating:5"`
+	AlbumIDs *[]string `json:"album_ids,omitempty" example:"alb-003,alb-001"` // Replaces the albums and their order
+	Sort     *string   `json:"sort,omitempty" example:"release_year"`         // An empty string restores the default order
+	Order    *string   `json:"order,omitempty" example:"desc"`
+}
+
+// SearchResult is an album, artist or genre matching a search
+// @Description A single search hit
+type SearchResult struct {
+	Type    string  `json:"type" example:"album" enums:"album,artist,genre"`
+	ID      string  `json:"id" example:"alb-001"`
+	Name    string  `json:"name" example:"The Dark Side of the Moon"`                                           // Album title, artist or genre name
+	Snippet string  `json:"snippet" example:"The Dark Side of the Moon · <mark>Pink</mark> <mark>Floyd</mark>"` // HTML-escaped text with the matches wrapped in <mark>
+	Score   float64 `json:"score" example:"0.83"`                                                               // Relevance, higher is better
+	Href    string  `json:"href" example:"/albums/alb-001"`                                                     // Path of the matching resource
+}
+
+// SearchPage is a single page of search results, best match first
+// @Description A page of search results
+type SearchPage struct {
+	Items      []SearchResult `json:"items"`
+	Total      int            `json:"total" example:"3"`                  // Number of matching results
+	Limit      int            `json:"limit" example:"20"`                 // Page size
+	Offset     int            `json:"offset" example:"0"`                 // Offset of the first item in the page
+	NextOffset *int           `json:"next_offset,omitempty" example:"20"` // Offset of the next page, omitted on the last page
+}
+
 // AlbumCondition represents the physical condition of a vinyl record
 // @Description Physical condition of a vinyl record
 type AlbumCondition string
@@ -43,8 +136,41 @@ const (
 	ConditionPoor      AlbumCondition = "Poor"
 )
 
-// ErrorResponse standardizes error responses
-// @Description Standard error response format
-type ErrorResponse struct {
-	Error string `json:"error" example:"Failed to retrieve album"`
+// AlbumConditions lists every condition from best to worst
+var AlbumConditions = []AlbumCondition{
+	ConditionMint,
+	ConditionExcellent,
+	ConditionVeryGood,
+	ConditionGood,
+	ConditionFair,
+	ConditionPoor,
+}
+
+// IsValid reports whether the condition is one of the known conditions
+func (c AlbumCondition) IsValid() bool {
+	for _, condition := range AlbumConditions {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// Problem is an RFC 7807 problem details response, served as application/problem+json
+// @Description Standard error response format (RFC 7807)
+type Problem struct {
+	Type     string       `json:"type" example:"/problems/album-not-found"` // URI reference identifying the problem type
+	Title    string       `json:"title" example:"Album not found"`          // Short, human-readable summary
+	Status   int          `json:"status" example:"404"`                     // HTTP status code
+	Detail   string       `json:"detail,omitempty" example:"limit must be between 1 and 200"`
+	Instance string       `json:"instance,omitempty" example:"/albums/alb-404"` // Path of the request that failed
+	Code     string       `json:"code" example:"album_not_found"`               // Stable, machine-readable error code
+	Errors   []FieldError `json:"errors,omitempty"`                             // Every invalid field of a rejected request
+}
+
+// FieldError describes why a single field was rejected
+// @Description A single invalid field
+type FieldError struct {
+	Field   string `json:"field" example:"rating"`
+	Message string `json:"message" example:"must be at most 5"`
 }
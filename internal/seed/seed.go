@@ -0,0 +1,96 @@
+// Package seed loads sample data into a store, from the embedded fixture sets or generated on the fly
+package seed
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+)
+
+// fixtureFiles holds the named fixture sets, one JSON file per set
+//
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// Fixture is a set of records to load into a store
+type Fixture struct {
+	Artists []models.Artist `json:"artists"`
+	Genres  []models.Genre  `json:"genres"`
+	Albums  []models.Album  `json:"albums"`
+}
+
+// Result counts the records created by Apply and the ones skipped because they already existed
+type Result struct {
+	Created int
+	Skipped int
+}
+
+// Names returns the names of the embedded fixture sets, sorted
+func Names() []string {
+	files, _ := fs.Glob(fixtureFiles, "fixtures/*.json")
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = strings.TrimSuffix(path.Base(file), ".json")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load reads the embedded fixture set with the given name
+func Load(name string) (*Fixture, error) {
+	raw, err := fixtureFiles.ReadFile("fixtures/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown fixture set %q, available sets are %s", name, strings.Join(Names(), ", "))
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		return nil, fmt.Errorf("fixture set %q: %w", name, err)
+	}
+	return &fixture, nil
+}
+
+// Apply creates the artists, genres and albums of the fixture, in that order.
+// Records whose ID is already taken are skipped, so applying a fixture twice is harmless.
+func Apply(ctx context.Context, store db.Store, fixture *Fixture) (Result, error) {
+	var result Result
+
+	create := func(kind, id string, err error) error {
+		switch {
+		case errors.Is(err, db.ErrConflict):
+			result.Skipped++
+		case err != nil:
+			return fmt.Errorf("creating %s %s: %w", kind, id, err)
+		default:
+			result.Created++
+		}
+		return nil
+	}
+
+	for _, artist := range fixture.Artists {
+		if err := create("artist", artist.ID, store.CreateArtist(ctx, artist)); err != nil {
+			return result, err
+		}
+	}
+	for _, genre := range fixture.Genres {
+		if err := create("genre", genre.ID, store.CreateGenre(ctx, genre)); err != nil {
+			return result, err
+		}
+	}
+	for _, album := range fixture.Albums {
+		if err := create("album", album.ID, store.CreateAlbum(ctx, album)); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer starts a span for every query run by a pgx connection pool
+type queryTracer struct{}
+
+// QueryTracer returns a pgx tracer that records every query as a child span of the
+// request that ran it, for db.ConnectOptions.Tracers. The spans carry the SQL but not
+// its arguments, which may hold user data.
+func QueryTracer() pgx.QueryTracer {
+	return queryTracer{}
+}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation := db.QueryOperation(data.SQL)
+	verb, table, _ := strings.Cut(operation, " ")
+
+	attributes := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(verb),
+		semconv.DBQueryText(strings.TrimSpace(data.SQL)),
+	}
+	if table != "" {
+		attributes = append(attributes, semconv.DBCollectionName(table))
+	}
+
+	ctx, _ = otel.Tracer(instrumentationName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateArtist tests the POST /artists endpoint
+func TestCreateArtist(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO artists (id, name) VALUES ($1, $2)")).
+		WithArgs("art-test", "Kraftwerk").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	// Set up router
+	router := gin.Default()
+	router.POST("/artists", handler.CreateArtist)
+
+	jsonValue, _ := json.Marshal(models.Artist{ID: "art-test", Name: "Kraftwerk"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/artists", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]string
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "art-test", response["id"])
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestUpdateArtistNotFound tests that PUT /artists/:id reports unknown artists
+func TestUpdateArtistNotFound(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE artists SET name = $2 WHERE id = $1")).
+		WithArgs("art-404", "Nobody").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// Set up router
+	router := gin.Default()
+	router.PUT("/artists/:id", handler.UpdateArtist)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/artists/art-404", bytes.NewBufferString(`{"name":"Nobody"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchArtist tests the PATCH /artists/:id endpoint
+func TestPatchArtist(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	name := "Pink Floyd"
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE artists SET name = COALESCE($2, name) WHERE id = $1")).
+		WithArgs("art-001", &name).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name FROM artists WHERE id = $1")).
+		WithArgs("art-001").
+		WillReturnRows(mock.NewRows([]string{"id", "name"}).AddRow("art-001", "Pink Floyd"))
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/artists/:id", handler.PatchArtist)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/artists/art-001", bytes.NewBufferString(`{"name":"Pink Floyd"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var artist models.Artist
+	err = json.Unmarshal(w.Body.Bytes(), &artist)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pink Floyd", artist.Name)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchArtistNotFound tests that PATCH /artists/:id reports unknown artists
+func TestPatchArtistNotFound(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE artists SET name = COALESCE($2, name) WHERE id = $1")).
+		WithArgs("art-404", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/artists/:id", handler.PatchArtist)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/artists/art-404", bytes.NewBufferString(`{"name":"Nobody"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestDeleteArtistWithAlbums tests that artists with albums can't be deleted
+func TestDeleteArtistWithAlbums(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM albums WHERE artist_id = $1")).
+		WithArgs("art-001").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
+	// Set up router
+	router := gin.Default()
+	router.DELETE("/artists/:id", handler.DeleteArtist)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/artists/art-001", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestDeleteArtist tests the DELETE /artists/:id endpoint
+func TestDeleteArtist(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM albums WHERE artist_id = $1")).
+		WithArgs("art-006").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM artists WHERE id = $1")).
+		WithArgs("art-006").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	// Set up router
+	router := gin.Default()
+	router.DELETE("/artists/:id", handler.DeleteArtist)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/artists/art-006", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
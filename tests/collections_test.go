@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCollectionsRouter serves the collection routes from a seeded in-memory store
+func newCollectionsRouter(t *testing.T) (*gin.Engine, db.Store) {
+	store := db.NewMemoryStore()
+	seedStore(t, store)
+
+	router := gin.Default()
+	api.RegisterRoutes(router, store)
+	return router, store
+}
+
+// serveJSON sends a request with an optional JSON body and returns the recorded response
+func serveJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestSmartCollectionAlbums tests that a smart collection follows the albums as they change
+func TestSmartCollectionAlbums(t *testing.T) {
+	router, store := newCollectionsRouter(t)
+
+	w := serveJSON(router, "POST", "/collections", `{"name":"5-star rock","kind":"smart","query":"genre:rock rating:5","sort":"release_year","order":"desc"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Regexp(t, `^col-[0-9a-f]{8}$`, created["id"])
+	path := "/collections/" + created["id"] + "/albums"
+
+	w = serveJSON(router, "GET", path, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var page models.AlbumPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"alb-001"}, albumIDs(&page))
+
+	// Membership updates along with the albums, in the collection's order
+	rating := 5
+	require.NoError(t, store.PatchAlbum(context.Background(), "alb-003", models.AlbumPatch{Rating: &rating}, models.AlbumPatch{}))
+
+	w = serveJSON(router, "GET", path, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"alb-003", "alb-001"}, albumIDs(&page))
+
+	// Request parameters narrow the collection down and override its sort
+	w = serveJSON(router, "GET", path+"?query=year:..1975&sort=title", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"alb-001"}, albumIDs(&page))
+
+	w = serveJSON(router, "GET", path+"?sort=title", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"alb-003", "alb-001"}, albumIDs(&page))
+
+	w = serveJSON(router, "GET", path+"?query=year:19x0", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_query")
+
+	w = serveJSON(router, "GET", "/collections/col-404/albums", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "collection_not_found")
+}
+
+// TestManualCollectionAlbums tests creating, reordering and evaluating a manual collection
+func TestManualCollectionAlbums(t *testing.T) {
+	router, _ := newCollectionsRouter(t)
+
+	w := serveJSON(router, "POST", "/collections", `{"id":"col-001","name":"Favourites","kind":"manual","album_ids":["alb-002","alb-003"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serveJSON(router, "PATCH", "/collections/col-001", `{"album_ids":["alb-003","alb-001","alb-002"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var collection models.Collection
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &collection))
+	assert.Equal(t, []string{"alb-003", "alb-001", "alb-002"}, collection.AlbumIDs)
+
+	w = serveJSON(router, "GET", "/collections/col-001/albums?limit=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var page models.AlbumPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, []string{"alb-003", "alb-001"}, albumIDs(&page))
+
+	// Without a sort, a descending order reverses the collection
+	w = serveJSON(router, "GET", "/collections/col-001/albums?order=desc", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, []string{"alb-002", "alb-001", "alb-003"}, albumIDs(&page))
+
+	// A manual collection can't take a query, and its albums must exist
+	w = serveJSON(router, "PATCH", "/collections/col-001", `{"query":"rock"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "must be empty when kind is manual")
+
+	w = serveJSON(router, "PATCH", "/collections/col-001", `{"album_ids":["alb-404"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_reference")
+
+	w = serveJSON(router, "DELETE", "/collections/col-001", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serveJSON(router, "GET", "/collections/col-001", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestCreateCollectionInvalid tests that collections are validated before they're stored
+func TestCreateCollectionInvalid(t *testing.T) {
+	// The store is never reached, so none is needed
+	handler := api.NewHandler(nil)
+
+	router := gin.Default()
+	router.POST("/collections", handler.CreateCollection)
+
+	tests := []struct {
+		body    string
+		field   string
+		message string
+	}{
+		{`{"name":"Jazz","kind":"smart"}`, "query", "is required when kind is smart"},
+		{`{"name":"Jazz","kind":"smart","query":"genre:jazz","album_ids":["alb-002"]}`, "album_ids", "must be empty when kind is smart"},
+		{`{"name":"Jazz","kind":"manual","query":"genre:jazz"}`, "query", "must be empty when kind is manual"},
+		{`{"name":"Jazz","kind":"smart","query":"genre:jazz year:19x0"}`, "query", "is not a valid album query: year must be"},
+		{`{"name":"Jazz","kind":"manual","album_ids":["alb-002","alb-002"]}`, "album_ids", "must not contain duplicates"},
+		{`{"name":"Jazz","kind":"playlist"}`, "kind", "must be one of smart, manual"},
+		{`{"name":"Jazz","kind":"manual","sort":"label"}`, "sort", "must be one of title, artist, release_year, rating, condition"},
+		{`{"name":" ","kind":"manual"}`, "name", "must not be blank"},
+	}
+	for _, tt := range tests {
+		w := serveJSON(router, "POST", "/collections", tt.body)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, tt.body)
+
+		var problem models.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		if assert.Len(t, problem.Errors, 1, tt.body) {
+			assert.Equal(t, tt.field, problem.Errors[0].Field, tt.body)
+			assert.Contains(t, problem.Errors[0].Message, tt.message, tt.body)
+		}
+	}
+}
+
+// TestCreateCollection tests that a manual collection and its albums are stored in one transaction
+func TestCreateCollection(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO collections (id, name, kind, query, sort, sort_order)")).
+		WithArgs("col-001", "Favourites", models.CollectionManual, "", "", "").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO collection_albums (collection_id, album_id, position)")+"(?s).*"+
+		regexp.QuoteMeta("unnest($2::text[]) WITH ORDINALITY")).
+		WithArgs("col-001", []string{"alb-003", "alb-001"}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	router := gin.Default()
+	router.POST("/collections", handler.CreateCollection)
+
+	w := serveJSON(router, "POST", "/collections", `{"id":"col-001","name":"Favourites","kind":"manual","album_ids":["alb-003","alb-001"]}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestGetManualCollectionAlbums tests that a manual collection is read in its own order
+func TestGetManualCollectionAlbums(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM collections WHERE id = $1")).
+		WithArgs("col-001").
+		WillReturnRows(mock.NewRows([]string{"id", "name", "kind", "query", "sort", "sort_order"}).
+			AddRow("col-001", "Favourites", "manual", "", "", ""))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT album_id FROM collection_albums")).
+		WithArgs("col-001").
+		WillReturnRows(mock.NewRows([]string{"album_id"}).AddRow("alb-003").AddRow("alb-001"))
+
+	join := regexp.QuoteMeta("JOIN collection_albums ca ON ca.album_id = a.id AND ca.collection_id = $2")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")+"(?s).*"+join+"(?s).*"+regexp.QuoteMeta("WHERE a.rating >= $1")).
+		WithArgs(4, "col-001").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+
+	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+		AddRow("alb-003", "Animals", "art-001", "Pink Floyd", "1977", "gen-001", "Rock", "🎸", "", 4, "Mint").
+		AddRow("alb-001", "The Dark Side of the Moon", "art-001", "Pink Floyd", "1973", "gen-001", "Rock", "🎸", "Original pressing", 5, "Excellent")
+	mock.ExpectQuery(join+"(?s).*"+regexp.QuoteMeta("ORDER BY ca.position, a.id")+"(?s).*"+regexp.QuoteMeta("LIMIT $3 OFFSET $4")).
+		WithArgs(4, "col-001", 50, 0).
+		WillReturnRows(rows)
+
+	router := gin.Default()
+	router.GET("/collections/:id/albums", handler.GetCollectionAlbums)
+
+	w := serveJSON(router, "GET", "/collections/col-001/albums?min_rating=4", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var page models.AlbumPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, []string{"alb-003", "alb-001"}, albumIDs(&page))
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCORSRouter serves the API behind the CORS policy loaded from the given settings
+func setupCORSRouter(t *testing.T, settings string) *gin.Engine {
+	t.Helper()
+
+	cfg, err := config.Load(writeConfig(t, minimalConfig+settings))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(api.CORS(cfg.CORS))
+	api.RegisterRoutes(router, db.NewMemoryStore())
+	return router
+}
+
+// preflight sends an OPTIONS request the way a browser does before a cross-origin request
+func preflight(router *gin.Engine, path, origin, method, headers string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+const corsSettings = `
+cors:
+  allowed_origins:
+    - https://vinylvault.example.com
+    - https://*.records.example.com
+  allowed_headers: [Content-Type, Authorization]
+  exposed_headers: [X-Request-ID]
+  allow_credentials: true
+  max_age: 10m
+`
+
+// TestCORSPatchPreflight tests the preflight a browser sends before a JSON merge patch
+func TestCORSPatchPreflight(t *testing.T) {
+	router := setupCORSRouter(t, corsSettings)
+
+	w := preflight(router, "/albums/alb-001", "https://vinylvault.example.com", "PATCH", "content-type,authorization")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://vinylvault.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "PATCH")
+	assert.Equal(t, "Content-Type,Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+}
+
+// TestCORSOrigins tests which origins are allowed by explicit and wildcard entries
+func TestCORSOrigins(t *testing.T) {
+	router := setupCORSRouter(t, corsSettings)
+
+	testCases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://vinylvault.example.com", true},
+		{"https://shop.records.example.com", true},
+		{"https://eu.shop.records.example.com", true},
+		{"https://records.example.com", false},
+		{"http://shop.records.example.com", false},
+		{"https://records.example.com.evil.test", false},
+		{"https://evil.test", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.origin, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/genres", nil)
+			req.Header.Set("Origin", tc.origin)
+			router.ServeHTTP(w, req)
+
+			if tc.allowed {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, tc.origin, w.Header().Get("Access-Control-Allow-Origin"))
+				assert.Equal(t, "X-Request-Id", w.Header().Get("Access-Control-Expose-Headers"))
+			} else {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+// TestCORSDefaults tests that the default policy allows any origin without credentials
+func TestCORSDefaults(t *testing.T) {
+	router := setupCORSRouter(t, "")
+
+	w := preflight(router, "/albums/alb-001", "https://anywhere.test", "PATCH", "content-type,if-none-match")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET,POST,PUT,PATCH,DELETE,OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "If-None-Match")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "43200", w.Header().Get("Access-Control-Max-Age"))
+}
+
+// TestCORSConfigValidation tests that unsafe or malformed CORS settings are rejected
+func TestCORSConfigValidation(t *testing.T) {
+	_, err := config.Load(writeConfig(t, minimalConfig+`
+cors:
+  allowed_origins: ["*", "https://*example.com", "https://vinylvault.example.com/app"]
+  allowed_methods: [get, TRACE]
+  allow_credentials: true
+  max_age: -1m
+`))
+	require.Error(t, err)
+
+	for _, message := range []string{
+		`cors.allowed_origins: "https://*example.com" may only use * for the subdomain`,
+		`cors.allowed_origins: "https://vinylvault.example.com/app" must be a scheme and host without a path`,
+		"cors.allow_credentials: can't be combined with allowed origin *",
+		`cors.allowed_methods: "TRACE" must be one of`,
+		"cors.max_age: must not be negative",
+	} {
+		assert.Contains(t, err.Error(), message)
+	}
+	assert.NotContains(t, err.Error(), `"GET"`)
+}
+
+// TestCORSEnvOverrides tests the CORS environment variables
+func TestCORSEnvOverrides(t *testing.T) {
+	t.Setenv("VINYLVAULT_CORS_ALLOWED_ORIGINS", "https://*.example.com")
+	t.Setenv("VINYLVAULT_CORS_ALLOWED_METHODS", "GET,PATCH")
+	t.Setenv("VINYLVAULT_CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("VINYLVAULT_CORS_MAX_AGE", "1h")
+
+	cfg, err := config.Load(writeConfig(t, minimalConfig))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://*.example.com"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, []string{"GET", "PATCH"}, cfg.CORS.AllowedMethods)
+	assert.True(t, cfg.CORS.AllowCredentials)
+	assert.Equal(t, time.Hour, cfg.CORS.MaxAge)
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateAlbumConstraintViolations tests that Postgres constraint violations
+// are reported as 409/422 responses naming the offending field
+func TestCreateAlbumConstraintViolations(t *testing.T) {
+	tests := []struct {
+		name   string
+		pgErr  *pgconn.PgError
+		status int
+		code   string
+		field  string
+	}{
+		{"unknown artist", &pgconn.PgError{Code: "23503", ConstraintName: "albums_artist_id_fkey"}, http.StatusUnprocessableEntity, "invalid_reference", "artist_id"},
+		{"unknown genre", &pgconn.PgError{Code: "23503", ConstraintName: "albums_genre_id_fkey"}, http.StatusUnprocessableEntity, "invalid_reference", "genre_id"},
+		{"duplicate id", &pgconn.PgError{Code: "23505", ConstraintName: "albums_pkey"}, http.StatusConflict, "already_exists", "id"},
+		{"rating out of range", &pgconn.PgError{Code: "23514", ConstraintName: "albums_rating_check"}, http.StatusUnprocessableEntity, "invalid_value", "rating"},
+		{"unknown condition", &pgconn.PgError{Code: "23514", ConstraintName: "albums_condition_check"}, http.StatusUnprocessableEntity, "invalid_value", "condition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set up mock database
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Unable to create mock database connection: %v", err)
+			}
+			defer mock.Close()
+			handler := api.NewHandler(db.NewPostgresStore(mock))
+
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO albums")).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnError(tt.pgErr)
+
+			// Set up router
+			router := gin.Default()
+			router.POST("/albums", handler.CreateAlbum)
+
+			jsonValue, _ := json.Marshal(models.Album{
+				ID:          "alb-001",
+				Title:       "Test Album",
+				ArtistID:    "art-001",
+				ReleaseYear: "2023",
+				GenreID:     "gen-001",
+				Rating:      4,
+				Condition:   models.ConditionMint,
+			})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/albums", bytes.NewBuffer(jsonValue))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+
+			var response models.Problem
+			err = json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.code, response.Code)
+			assert.Equal(t, tt.status, response.Status)
+			if assert.Len(t, response.Errors, 1) {
+				assert.Equal(t, tt.field, response.Errors[0].Field)
+			}
+
+			// Check expectations
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+// TestProblemResponses tests the problem details returned for missing records and unknown routes
+func TestProblemResponses(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE a.id = $1")).
+		WithArgs("alb-404").
+		WillReturnRows(mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}))
+
+	// Set up router with all routes
+	router := gin.Default()
+	api.RegisterRoutes(router, db.NewPostgresStore(mock))
+
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		code     string
+		instance string
+	}{
+		{"GET", "/albums/alb-404", http.StatusNotFound, "album_not_found", "/albums/alb-404"},
+		{"GET", "/albums?limit=500", http.StatusBadRequest, "invalid_parameter", "/albums"},
+		{"GET", "/turntables", http.StatusNotFound, "route_not_found", "/turntables"},
+		{"POST", "/albums/alb-001", http.StatusMethodNotAllowed, "method_not_allowed", "/albums/alb-001"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, tt.path)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"), tt.path)
+
+		var problem models.Problem
+		err = json.Unmarshal(w.Body.Bytes(), &problem)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.code, problem.Code, tt.path)
+		assert.Equal(t, tt.status, problem.Status, tt.path)
+		assert.Equal(t, tt.instance, problem.Instance, tt.path)
+	}
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestQueryTimeout tests that queries outliving the request deadline are cancelled and reported as a 504
+func TestQueryTimeout(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE a.id = $1")).
+		WithArgs("alb-001").
+		WillReturnRows(mock.NewRows([]string{"id"})).
+		WillDelayFor(time.Second)
+
+	// Set up router
+	router := gin.Default()
+	router.Use(api.QueryTimeout(20 * time.Millisecond))
+	router.GET("/albums/:id", handler.GetAlbumByID)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums/alb-001", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	var problem models.Problem
+	err = json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.NoError(t, err)
+	assert.Equal(t, "query_timeout", problem.Code)
+}
+
+// TestDeleteAlbumConstraintViolation tests that a delete rejected by a constraint gets a typed problem instead of a 500
+func TestDeleteAlbumConstraintViolation(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM albums WHERE id = $1")).
+		WithArgs("alb-001").
+		WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "collection_albums_album_id_fkey"})
+
+	router := gin.Default()
+	router.DELETE("/albums/:id", handler.DeleteAlbum)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/albums/alb-001", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response models.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "invalid_reference", response.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
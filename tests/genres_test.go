@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateGenre tests the POST /genres endpoint
+func TestCreateGenre(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO genres (id, name, icon) VALUES ($1, $2, $3)")).
+		WithArgs("gen-test", "Ambient", "🌌").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	// Set up router
+	router := gin.Default()
+	router.POST("/genres", handler.CreateGenre)
+
+	jsonValue, _ := json.Marshal(models.Genre{ID: "gen-test", Name: "Ambient", Icon: "🌌"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/genres", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]string
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "gen-test", response["id"])
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchGenreIcon tests changing only the icon with PATCH /genres/:id
+func TestPatchGenreIcon(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	icon := "🤘"
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE genres SET name = COALESCE($2, name), icon = COALESCE($3, icon) WHERE id = $1")).
+		WithArgs("gen-001", (*string)(nil), &icon).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, icon FROM genres WHERE id = $1")).
+		WithArgs("gen-001").
+		WillReturnRows(mock.NewRows([]string{"id", "name", "icon"}).AddRow("gen-001", "Rock", "🤘"))
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/genres/:id", handler.PatchGenre)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/genres/gen-001", bytes.NewBufferString(`{"icon":"🤘"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var genre models.Genre
+	err = json.Unmarshal(w.Body.Bytes(), &genre)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rock", genre.Name)
+	assert.Equal(t, "🤘", genre.Icon)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestGetGenreAlbums tests the GET /genres/:id/albums endpoint
+func TestGetGenreAlbums(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, icon FROM genres WHERE id = $1")).
+		WithArgs("gen-002").
+		WillReturnRows(mock.NewRows([]string{"id", "name", "icon"}).AddRow("gen-002", "Jazz", "🎷"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)") + "(?s).*" + regexp.QuoteMeta("WHERE a.genre_id = $1")).
+		WithArgs("gen-002").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+		AddRow("alb-002", "Kind of Blue", "art-003", "Miles Davis", "1959", "gen-002", "Jazz", "🎷", "Columbia pressing", 5, "Very Good")
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE a.genre_id = $1")).
+		WithArgs("gen-002", db.DefaultAlbumLimit, 0).
+		WillReturnRows(rows)
+
+	// Set up router
+	router := gin.Default()
+	router.GET("/genres/:id/albums", handler.GetGenreAlbums)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/genres/gen-002/albums", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var page models.AlbumPage
+	err = json.Unmarshal(w.Body.Bytes(), &page)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, "Kind of Blue", page.Items[0].Title)
+		assert.Equal(t, "Jazz", page.Items[0].Genre.Name)
+	}
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestGetGenreAlbumsUnknownGenre tests that albums of a missing genre return 404
+func TestGetGenreAlbumsUnknownGenre(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, icon FROM genres WHERE id = $1")).
+		WithArgs("gen-404").
+		WillReturnRows(mock.NewRows([]string{"id", "name", "icon"}))
+
+	// Set up router
+	router := gin.Default()
+	router.GET("/genres/:id/albums", handler.GetGenreAlbums)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/genres/gen-404/albums", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
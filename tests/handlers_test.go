@@ -12,6 +12,7 @@ import (
 	"github.com/emirhanalptekin/vinylvault/internal/db"
 	"github.com/emirhanalptekin/vinylvault/internal/models"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,24 +41,25 @@ func TestGetAlbums(t *testing.T) {
 		t.Fatalf("Unable to create mock database connection: %v", err)
 	}
 	defer mock.Close()
-	db.SetDBPool(mock)
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	// The total count is queried before the page itself
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
 
 	// Define expected rows returned from the database
 	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
 		AddRow("alb-001", "The Dark Side of the Moon", "art-001", "Pink Floyd", "1973", "gen-001", "Rock", "🎸", "Original pressing", 5, "Excellent")
 
 	// Set up expected query with regular expression for flexibility
-	queryRegex := regexp.QuoteMeta(`
-		SELECT a.id, a.title, a.artist_id, ar.name, a.release_year, a.genre_id, g.name, g.icon, a.notes, a.rating, a.condition 
-		FROM albums a
-		JOIN artists ar ON a.artist_id = ar.id
-		JOIN genres g ON a.genre_id = g.id
-	`)
-	mock.ExpectQuery(queryRegex).WillReturnRows(rows)
+	queryRegex := regexp.QuoteMeta("SELECT a.id, a.title, a.artist_id, ar.name, a.release_year, a.genre_id, g.name, g.icon, a.notes, a.rating, a.condition") +
+		"(?s).*" + regexp.QuoteMeta("ORDER BY a.title ASC, a.id ASC") +
+		"(?s).*" + regexp.QuoteMeta("LIMIT $1 OFFSET $2")
+	mock.ExpectQuery(queryRegex).WithArgs(db.DefaultAlbumLimit, 0).WillReturnRows(rows)
 
 	// Set up router with the albums route
 	router := gin.Default()
-	router.GET("/albums", api.GetAlbums)
+	router.GET("/albums", handler.GetAlbums)
 
 	// Create a request to send to the above route
 	w := httptest.NewRecorder()
@@ -68,12 +70,14 @@ func TestGetAlbums(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// Check that the response contains the expected album data
-	var albums []models.Album
-	err = json.Unmarshal(w.Body.Bytes(), &albums)
+	var page models.AlbumPage
+	err = json.Unmarshal(w.Body.Bytes(), &page)
 	assert.NoError(t, err)
-	assert.Len(t, albums, 1)
-	assert.Equal(t, "alb-001", albums[0].ID)
-	assert.Equal(t, "The Dark Side of the Moon", albums[0].Title)
+	assert.Equal(t, 1, page.Total)
+	assert.Nil(t, page.NextOffset)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "alb-001", page.Items[0].ID)
+	assert.Equal(t, "The Dark Side of the Moon", page.Items[0].Title)
 
 	// Ensure all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -81,6 +85,71 @@ func TestGetAlbums(t *testing.T) {
 	}
 }
 
+// TestGetAlbumsFiltered tests filtering, sorting and pagination on GET /albums
+func TestGetAlbumsFiltered(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	where := regexp.QuoteMeta("WHERE a.genre_id = $1 AND a.condition IN ($2, $3) AND a.rating >= $4 AND a.release_year >= $5 AND a.release_year <= $6")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")+"(?s).*"+where).
+		WithArgs("gen-001", models.ConditionMint, models.ConditionExcellent, 4, "1970", "1979").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+
+	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+		AddRow("alb-001", "The Dark Side of the Moon", "art-001", "Pink Floyd", "1973", "gen-001", "Rock", "🎸", "Original pressing", 5, "Excellent")
+	mock.ExpectQuery(where+"(?s).*"+regexp.QuoteMeta("ORDER BY a.rating DESC, a.id DESC")+"(?s).*"+regexp.QuoteMeta("LIMIT $7 OFFSET $8")).
+		WithArgs("gen-001", models.ConditionMint, models.ConditionExcellent, 4, "1970", "1979", 1, 1).
+		WillReturnRows(rows)
+
+	// Set up router with the albums route
+	router := gin.Default()
+	router.GET("/albums", handler.GetAlbums)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums?genre_id=gen-001&condition=Mint,Excellent&min_rating=4&min_year=1970&max_year=1979&sort=rating&order=desc&limit=1&offset=1", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var page models.AlbumPage
+	err = json.Unmarshal(w.Body.Bytes(), &page)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, 1, page.Limit)
+	assert.Equal(t, 1, page.Offset)
+	if assert.NotNil(t, page.NextOffset) {
+		assert.Equal(t, 2, *page.NextOffset)
+	}
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestGetAlbumsInvalidParams tests that bad query parameters are rejected before hitting the database
+func TestGetAlbumsInvalidParams(t *testing.T) {
+	// The store is never reached, so none is needed
+	handler := api.NewHandler(nil)
+
+	router := gin.Default()
+	router.GET("/albums", handler.GetAlbums)
+
+	for _, query := range []string{"limit=abc", "limit=1000", "sort=notes", "order=sideways", "condition=Scratched", "min_year=1990&max_year=1980"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 // TestGetAlbumByID tests the GET /albums/:id endpoint
 func TestGetAlbumByID(t *testing.T) {
 	// Set up mock database
@@ -89,7 +158,7 @@ func TestGetAlbumByID(t *testing.T) {
 		t.Fatalf("Unable to create mock database connection: %v", err)
 	}
 	defer mock.Close()
-	db.SetDBPool(mock)
+	handler := api.NewHandler(db.NewPostgresStore(mock))
 
 	// Set up expected query and rows
 	queryRegex := regexp.QuoteMeta(`
@@ -106,7 +175,7 @@ func TestGetAlbumByID(t *testing.T) {
 
 	// Set up router with the album by ID route
 	router := gin.Default()
-	router.GET("/albums/:id", api.GetAlbumByID)
+	router.GET("/albums/:id", handler.GetAlbumByID)
 
 	// Create a request to send
 	w := httptest.NewRecorder()
@@ -139,7 +208,7 @@ func TestCreateAlbum(t *testing.T) {
 		t.Fatalf("Unable to create mock database connection: %v", err)
 	}
 	defer mock.Close()
-	db.SetDBPool(mock)
+	handler := api.NewHandler(db.NewPostgresStore(mock))
 
 	// Define the album to create
 	album := models.Album{
@@ -163,7 +232,7 @@ func TestCreateAlbum(t *testing.T) {
 
 	// Set up router
 	router := gin.Default()
-	router.POST("/albums", api.CreateAlbum)
+	router.POST("/albums", handler.CreateAlbum)
 
 	// Create request body
 	jsonValue, _ := json.Marshal(album)
@@ -195,7 +264,7 @@ func TestUpdateAlbum(t *testing.T) {
 		t.Fatalf("Unable to create mock database connection: %v", err)
 	}
 	defer mock.Close()
-	db.SetDBPool(mock)
+	handler := api.NewHandler(db.NewPostgresStore(mock))
 
 	// Define the album to update
 	album := models.Album{
@@ -219,7 +288,7 @@ func TestUpdateAlbum(t *testing.T) {
 
 	// Set up router
 	router := gin.Default()
-	router.PUT("/albums/:id", api.UpdateAlbum)
+	router.PUT("/albums/:id", handler.UpdateAlbum)
 
 	// Create request
 	jsonValue, _ := json.Marshal(album)
@@ -251,7 +320,7 @@ func TestDeleteAlbum(t *testing.T) {
 		t.Fatalf("Unable to create mock database connection: %v", err)
 	}
 	defer mock.Close()
-	db.SetDBPool(mock)
+	handler := api.NewHandler(db.NewPostgresStore(mock))
 
 	// Set up expected query
 	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM albums WHERE id = $1")).
@@ -260,7 +329,7 @@ func TestDeleteAlbum(t *testing.T) {
 
 	// Set up router
 	router := gin.Default()
-	router.DELETE("/albums/:id", api.DeleteAlbum)
+	router.DELETE("/albums/:id", handler.DeleteAlbum)
 
 	// Create request
 	w := httptest.NewRecorder()
@@ -281,3 +350,163 @@ func TestDeleteAlbum(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+// TestUpdateAlbumNotFound tests that PUT /albums/:id reports albums that don't exist
+func TestUpdateAlbumNotFound(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	album := models.Album{
+		Title:       "Updated Album",
+		ArtistID:    "art-001",
+		ReleaseYear: "2023",
+		GenreID:     "gen-001",
+		Rating:      5,
+		Condition:   models.ConditionExcellent,
+	}
+
+	// No rows are touched when the album doesn't exist
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE albums")).
+		WithArgs("nope", album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// Set up router
+	router := gin.Default()
+	router.PUT("/albums/:id", handler.UpdateAlbum)
+
+	jsonValue, _ := json.Marshal(album)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/albums/nope", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestCreateAlbumWithPut tests create-on-PUT with an "If-None-Match: *" precondition
+func TestCreateAlbumWithPut(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	album := models.Album{
+		Title:       "Low",
+		ArtistID:    "art-002",
+		ReleaseYear: "1977",
+		GenreID:     "gen-001",
+		Rating:      5,
+		Condition:   models.ConditionGood,
+	}
+
+	// The first PUT creates the album, the second one trips over the primary key
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO albums")).
+		WithArgs("alb-low", album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO albums")).
+		WithArgs("alb-low", album.Title, album.ArtistID, album.ReleaseYear, album.GenreID, album.Notes, album.Rating, album.Condition).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "albums_pkey"})
+
+	// Set up router
+	router := gin.Default()
+	router.PUT("/albums/:id", handler.UpdateAlbum)
+
+	jsonValue, _ := json.Marshal(album)
+	for _, expected := range []int{http.StatusCreated, http.StatusPreconditionFailed} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/albums/alb-low", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-None-Match", "*")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, expected, w.Code)
+	}
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestDeleteAlbumNotFound tests that DELETE /albums/:id reports albums that don't exist
+func TestDeleteAlbumNotFound(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM albums WHERE id = $1")).
+		WithArgs("nope").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	// Set up router
+	router := gin.Default()
+	router.DELETE("/albums/:id", handler.DeleteAlbum)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/albums/nope", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestHandlersUseTheirOwnStore tests that two routers in one process can serve different databases
+func TestHandlersUseTheirOwnStore(t *testing.T) {
+	titles := []string{"Kind of Blue", "OK Computer"}
+
+	var routers []*gin.Engine
+	var mocks []pgxmock.PgxPoolIface
+	for _, title := range titles {
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Fatalf("Unable to create mock database connection: %v", err)
+		}
+		defer mock.Close()
+
+		rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+			AddRow("alb-001", title, "art-001", "Artist", "1970", "gen-001", "Genre", "", "", 5, "Mint")
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE a.id = $1")).WithArgs("alb-001").WillReturnRows(rows)
+
+		router := gin.Default()
+		api.RegisterRoutes(router, db.NewPostgresStore(mock))
+
+		routers = append(routers, router)
+		mocks = append(mocks, mock)
+	}
+
+	for i, router := range routers {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/albums/alb-001", nil)
+		router.ServeHTTP(w, req)
+
+		var album models.Album
+		err := json.Unmarshal(w.Body.Bytes(), &album)
+		assert.NoError(t, err)
+		assert.Equal(t, titles[i], album.Title)
+
+		if err := mocks[i].ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
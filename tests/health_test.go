@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/health"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getReadiness serves GET /readyz and decodes the report
+func getReadiness(t *testing.T, router *gin.Engine) (int, health.Report) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	router.ServeHTTP(w, req)
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+// TestLiveness tests that /healthz answers without touching the store
+func TestLiveness(t *testing.T) {
+	router := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+// TestReadinessSQLite tests the database, schema and pool checks against a SQLite store
+func TestReadinessSQLite(t *testing.T) {
+	store, err := db.OpenSQLite(filepath.Join(t.TempDir(), "vault.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	router := gin.New()
+	api.RegisterRoutes(router, store)
+
+	// The schema hasn't been created yet
+	code, report := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusFail, report.Checks["schema"].Status)
+	assert.Contains(t, report.Checks["schema"].Error, "expected")
+
+	migrator, err := store.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	code, report = getReadiness(t, router)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.ElementsMatch(t, []string{"database", "schema", "pool"}, mapKeys(report.Checks))
+	for name, result := range report.Checks {
+		assert.Equal(t, health.StatusOK, result.Status, name)
+		assert.GreaterOrEqual(t, result.LatencyMs, 0.0, name)
+	}
+	assert.Equal(t, map[string]interface{}{"version": float64(migrator.Latest()), "expected": float64(migrator.Latest())}, report.Checks["schema"].Details)
+	assert.Contains(t, report.Checks["pool"].Details, "max_conns")
+}
+
+// TestReadinessExtraChecks tests that registered checks are run and fail the probe
+func TestReadinessExtraChecks(t *testing.T) {
+	router := gin.New()
+	api.RegisterRoutes(router, db.NewMemoryStore(),
+		health.Check{Name: "cache", Check: func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("cache is down")
+		}},
+		health.Check{Name: "queue", Check: func(ctx context.Context) (interface{}, error) {
+			return map[string]int{"depth": 3}, nil
+		}},
+	)
+
+	code, report := getReadiness(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.Result{Status: health.StatusFail, LatencyMs: report.Checks["cache"].LatencyMs, Error: "cache is down"}, report.Checks["cache"])
+	assert.Equal(t, health.StatusOK, report.Checks["queue"].Status)
+	assert.Equal(t, map[string]interface{}{"depth": float64(3)}, report.Checks["queue"].Details)
+}
+
+// TestCheckerTimeout tests that a hanging check fails once the checker's timeout passes
+func TestCheckerTimeout(t *testing.T) {
+	checker := health.NewChecker(50 * time.Millisecond)
+	checker.Add("slow", func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	report := checker.Run(context.Background())
+	assert.False(t, report.OK())
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	assert.GreaterOrEqual(t, report.Checks["slow"].LatencyMs, 50.0)
+}
+
+// mapKeys returns the keys of a map in no particular order
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
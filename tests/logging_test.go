@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLoggedRouter registers the request ID, access log and recovery middleware in front
+// of the API and returns the JSON log lines written while serving
+func setupLoggedRouter(t *testing.T, store db.Store) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	router := gin.New()
+	router.Use(api.RequestID(logger), api.AccessLog(), api.Recovery())
+	api.RegisterRoutes(router, store)
+	return router, &logs
+}
+
+// logLines decodes the JSON log lines
+func logLines(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// TestRequestID tests that request IDs are honored, echoed and made up when missing
+func TestRequestID(t *testing.T) {
+	router, logs := setupLoggedRouter(t, db.NewMemoryStore())
+
+	testCases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"honored", "7f3c9a2e-upstream", "7f3c9a2e-upstream"},
+		{"missing", "", ""},
+		{"malformed", "two words\n", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.Reset()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/albums/alb-404", nil)
+			if tc.header != "" {
+				req.Header.Set(api.RequestIDHeader, tc.header)
+			}
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(api.RequestIDHeader)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, id)
+			} else {
+				assert.Regexp(t, `^[0-9a-f-]{36}$`, id)
+			}
+
+			lines := logLines(t, logs)
+			require.Len(t, lines, 1)
+			assert.Equal(t, "request", lines[0]["msg"])
+			assert.Equal(t, "WARN", lines[0]["level"])
+			assert.Equal(t, id, lines[0]["request_id"])
+			assert.Equal(t, "GET", lines[0]["method"])
+			assert.Equal(t, "/albums/:id", lines[0]["route"])
+			assert.Equal(t, "/albums/alb-404", lines[0]["path"])
+			assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+			assert.Contains(t, lines[0], "latency_ms")
+		})
+	}
+}
+
+// TestInternalErrorIsLogged tests that the store error behind a 500 is logged but not returned
+func TestInternalErrorIsLogged(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+		WillReturnError(errors.New("relation \"albums\" does not exist"))
+
+	router, logs := setupLoggedRouter(t, db.NewPostgresStore(mock))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums", nil)
+	req.Header.Set(api.RequestIDHeader, "req-500")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "does not exist")
+
+	lines := logLines(t, logs)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "ERROR", lines[0]["level"])
+	assert.Equal(t, "Failed to retrieve albums", lines[0]["msg"])
+	assert.Equal(t, `relation "albums" does not exist`, lines[0]["error"])
+	assert.Equal(t, "internal_error", lines[0]["code"])
+	assert.Equal(t, "req-500", lines[0]["request_id"])
+
+	assert.Equal(t, "request", lines[1]["msg"])
+	assert.Equal(t, "ERROR", lines[1]["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+}
+
+// TestRecoveryLogsPanics tests that a panicking handler gets a problem response and a log line
+func TestRecoveryLogsPanics(t *testing.T) {
+	router, logs := setupLoggedRouter(t, db.NewMemoryStore())
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "internal_error")
+
+	lines := logLines(t, logs)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "boom", lines[0]["panic"])
+	assert.Equal(t, w.Header().Get(api.RequestIDHeader), lines[0]["request_id"])
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+}
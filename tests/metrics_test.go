@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/metrics"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMetricsRouter serves the API and /metrics from the store
+func setupMetricsRouter(t *testing.T, store db.Store) (*gin.Engine, *metrics.Metrics) {
+	t.Helper()
+
+	m := metrics.New()
+	m.RegisterStore(store)
+
+	router := gin.New()
+	router.Use(m.Middleware())
+	api.RegisterRoutes(router, store)
+	router.GET("/metrics", gin.WrapH(m.Handler()))
+	return router, m
+}
+
+// scrape returns the /metrics page
+func scrape(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
+
+// TestHTTPMetrics tests that requests are counted and timed by route template and status
+func TestHTTPMetrics(t *testing.T) {
+	router, _ := setupMetricsRouter(t, db.NewMemoryStore())
+
+	for _, path := range []string{"/albums/alb-001", "/albums/alb-002", "/genres", "/wp-login.php"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+	}
+
+	page := scrape(t, router)
+	assert.Contains(t, page, `vinylvault_http_requests_total{method="GET",route="/albums/:id",status="404"} 2`)
+	assert.Contains(t, page, `vinylvault_http_requests_total{method="GET",route="/genres",status="200"} 1`)
+	assert.Contains(t, page, `vinylvault_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, page, `vinylvault_http_request_duration_seconds_count{method="GET",route="/albums/:id",status="404"} 2`)
+	assert.NotContains(t, page, "alb-001")
+	assert.NotContains(t, page, "wp-login")
+}
+
+// TestCollectionMetrics tests the album, artist and genre gauges
+func TestCollectionMetrics(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	require.NoError(t, store.CreateArtist(ctx, models.Artist{ID: "art-001", Name: "Pink Floyd"}))
+	require.NoError(t, store.CreateGenre(ctx, models.Genre{ID: "gen-001", Name: "Rock", Icon: "🎸"}))
+	require.NoError(t, store.CreateGenre(ctx, models.Genre{ID: "gen-002", Name: "Jazz", Icon: "🎷"}))
+	require.NoError(t, store.CreateAlbum(ctx, models.Album{ID: "alb-001", Title: "Animals", ArtistID: "art-001", ReleaseYear: "1977", GenreID: "gen-001", Rating: 4, Condition: models.ConditionMint}))
+
+	router, _ := setupMetricsRouter(t, store)
+
+	page := scrape(t, router)
+	assert.Contains(t, page, "vinylvault_albums 1\n")
+	assert.Contains(t, page, "vinylvault_artists 1\n")
+	assert.Contains(t, page, "vinylvault_genres 2\n")
+	// The memory store has no connection pool
+	assert.NotContains(t, page, "vinylvault_db_pool")
+}
+
+// TestCollectionMetricsCount tests that albums, artists and genres are counted by the database
+// rather than read in full
+func TestCollectionMetricsCount(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM albums")).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM artists")).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM genres")).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+
+	m := metrics.New()
+	m.RegisterStore(db.NewPostgresStore(mock))
+
+	page := scrapeRegistry(t, m)
+	assert.Contains(t, page, "vinylvault_albums 0\n")
+	assert.Contains(t, page, "vinylvault_artists 7\n")
+	assert.Contains(t, page, "vinylvault_genres 3\n")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPoolMetrics tests that the connection pool statistics are exported
+func TestPoolMetrics(t *testing.T) {
+	store, err := db.OpenSQLite(filepath.Join(t.TempDir(), "vault.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	migrator, err := store.Migrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	router, _ := setupMetricsRouter(t, store)
+
+	page := scrape(t, router)
+	for _, metric := range []string{
+		"vinylvault_db_pool_max_conns 1",
+		"vinylvault_db_pool_total_conns",
+		"vinylvault_db_pool_idle_conns",
+		"vinylvault_db_pool_acquired_conns",
+		"vinylvault_db_pool_wait_count_total",
+		"vinylvault_db_pool_wait_duration_seconds_total",
+		"vinylvault_albums 0",
+	} {
+		assert.Contains(t, page, metric)
+	}
+}
+
+// TestQueryMetrics tests that the query tracer times queries by operation and outcome
+func TestQueryMetrics(t *testing.T) {
+	m := metrics.New()
+	tracer := m.QueryTracer()
+
+	queries := []struct {
+		sql string
+		err error
+	}{
+		{"\n\t\tSELECT COUNT(*)\n\t\tFROM albums a\n\t\tJOIN artists ar ON a.artist_id = ar.id", nil},
+		{"SELECT id, name, icon FROM genres WHERE id = $1", nil},
+		{"INSERT INTO artists (id, name) VALUES ($1, $2)", errors.New("duplicate key")},
+		{"UPDATE albums SET title = $2 WHERE id = $1", nil},
+		{"DELETE FROM genres WHERE id = $1", nil},
+		{"SELECT pg_advisory_xact_lock($1)", nil},
+	}
+	for _, query := range queries {
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: query.sql})
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: query.err})
+	}
+
+	count, err := testutil.GatherAndCount(m.Registry(), "vinylvault_db_query_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+
+	page := scrapeRegistry(t, m)
+	assert.Contains(t, page, "# TYPE vinylvault_db_query_duration_seconds histogram")
+	for _, series := range []string{
+		`operation="select albums",outcome="success"`,
+		`operation="select genres",outcome="success"`,
+		`operation="insert artists",outcome="error"`,
+		`operation="update albums",outcome="success"`,
+		`operation="delete genres",outcome="success"`,
+		`operation="select",outcome="success"`,
+	} {
+		assert.Contains(t, page, "vinylvault_db_query_duration_seconds_count{"+series+"} 1")
+	}
+}
+
+// scrapeRegistry serves the metrics page without a router
+func scrapeRegistry(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	m.Handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	return w.Body.String()
+}
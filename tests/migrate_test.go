@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSQLiteMigrator opens an empty SQLite file and returns its path and migrator
+func newSQLiteMigrator(t *testing.T) (string, *db.Migrator) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vault.db")
+	store, err := db.OpenSQLite(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	migrator, err := store.Migrator()
+	require.NoError(t, err)
+	return path, migrator
+}
+
+// TestMigrateUpAndDown tests applying and reverting the embedded migrations
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	_, migrator := newSQLiteMigrator(t)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+	assert.ErrorIs(t, migrator.CheckVersion(ctx), db.ErrSchemaOutdated)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, applied)
+	assert.NoError(t, migrator.CheckVersion(ctx))
+
+	// Running up again is a no-op
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "migration %d should be applied", status.Version)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	if assert.Len(t, reverted, 1) {
+		assert.Equal(t, migrator.Latest(), reverted[0].Version)
+	}
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+}
+
+// TestMigrateRefusesNewerSchema tests that a database migrated by a newer binary is left alone
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	path, migrator := newSQLiteMigrator(t)
+
+	_, err := migrator.Up(ctx)
+	require.NoError(t, err)
+
+	conn, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Exec("UPDATE schema_migrations SET version = $1", migrator.Latest()+1)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, migrator.CheckVersion(ctx), db.ErrSchemaTooNew)
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, db.ErrSchemaTooNew)
+	_, err = migrator.Down(ctx, 1)
+	assert.ErrorIs(t, err, db.ErrSchemaTooNew)
+}
+
+// TestSchemaVersionOnlyReads tests that reading the version of an empty database, as the
+// readiness check does, doesn't create the version table
+func TestSchemaVersionOnlyReads(t *testing.T) {
+	ctx := context.Background()
+	path, migrator := newSQLiteMigrator(t)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+	assert.ErrorIs(t, migrator.CheckVersion(ctx), db.ErrSchemaOutdated)
+
+	conn, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	var tables int
+	require.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&tables))
+	assert.Equal(t, 0, tables)
+}
+
+// TestPostgresSchemaVersionOnlyReads tests that the Postgres version is read with a SELECT alone,
+// and that a missing version table means nothing has been applied
+func TestPostgresSchemaVersionOnlyReads(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+
+	migrator, err := db.NewPostgresStore(mock).Migrator()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations")).
+		WillReturnError(&pgconn.PgError{Code: "42P01", Message: `relation "schema_migrations" does not exist`})
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations")).
+		WillReturnRows(mock.NewRows([]string{"version", "dirty"}).AddRow(migrator.Latest(), false))
+
+	version, err := migrator.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+	assert.NoError(t, migrator.CheckVersion(context.Background()))
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestMigrateRemovesSampleData tests that the sample records migration 1 inserts are removed again,
+// except for edited ones and the artists and genres they refer to
+func TestMigrateRemovesSampleData(t *testing.T) {
+	ctx := context.Background()
+	path, migrator := newSQLiteMigrator(t)
+
+	conn, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	count := func(table string) int {
+		var n int
+		require.NoError(t, conn.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count("albums"))
+	assert.Equal(t, 0, count("artists"))
+	assert.Equal(t, 0, count("genres"))
+
+	// An install that kept the sample data and changed one of the albums
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count("albums"))
+	_, err = conn.Exec("UPDATE albums SET rating = 4 WHERE id = 'alb-001'")
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count("albums"))
+	assert.Equal(t, 1, count("artists"))
+	assert.Equal(t, 1, count("genres"))
+}
+
+// TestPostgresMigrateUp tests that Postgres migrations run under the migration lock and are
+// recorded in schema_migrations within the same transaction as the schema change
+func TestPostgresMigrateUp(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+
+	migrator, err := db.NewPostgresMigrator(mock)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations")).
+		WillReturnRows(mock.NewRows([]string{"version", "dirty"}))
+	for version := 1; version <= migrator.Latest(); version++ {
+		mock.ExpectBegin()
+		rows := mock.NewRows([]string{"version", "dirty"})
+		if version > 1 {
+			rows.AddRow(version-1, false)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations")).WillReturnRows(rows)
+		mock.ExpectExec("CREATE TABLE|ALTER TABLE|INSERT INTO|DELETE FROM").
+			WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations")).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)")).
+			WithArgs(version).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+	}
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, applied, migrator.Latest())
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPostgresMigrateUpAfterAnotherServer tests that a server which waited for the migration lock
+// reads the version again and finds nothing left to apply
+func TestPostgresMigrateUpAfterAnotherServer(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+
+	migrator, err := db.NewPostgresMigrator(mock)
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, dirty FROM schema_migrations")).
+		WillReturnRows(mock.NewRows([]string{"version", "dirty"}).AddRow(migrator.Latest(), false))
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
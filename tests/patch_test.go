@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectAlbumLookup sets up the mock to return a single album for GET /albums/:id style lookups
+func expectAlbumLookup(mock pgxmock.PgxPoolIface, rating int, condition models.AlbumCondition) {
+	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+		AddRow("alb-001", "The Dark Side of the Moon", "art-001", "Pink Floyd", "1973", "gen-001", "Rock", "🎸", "Original pressing", rating, condition)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE a.id = $1")).WithArgs("alb-001").WillReturnRows(rows)
+}
+
+// TestPatchAlbumMergePatch tests that a merge patch only updates the supplied columns
+func TestPatchAlbumMergePatch(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	expectAlbumLookup(mock, 5, models.ConditionExcellent)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE albums SET rating = $2 WHERE id = $1")).
+		WithArgs("alb-001", 4).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	expectAlbumLookup(mock, 4, models.ConditionExcellent)
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/albums/:id", handler.PatchAlbum)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/albums/alb-001", bytes.NewBufferString(`{"rating":4}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var album models.Album
+	err = json.Unmarshal(w.Body.Bytes(), &album)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, album.Rating)
+	assert.Equal(t, "Pink Floyd", album.Artist.Name)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchAlbumJSONPatch tests RFC 6902 JSON Patch support
+func TestPatchAlbumJSONPatch(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	expectAlbumLookup(mock, 5, models.ConditionExcellent)
+	// The tested condition is checked again by the update
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE albums SET condition = $2 WHERE id = $1 AND condition = $3")).
+		WithArgs("alb-001", models.ConditionVeryGood, models.ConditionExcellent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	expectAlbumLookup(mock, 5, models.ConditionVeryGood)
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/albums/:id", handler.PatchAlbum)
+
+	patch := `[
+		{"op": "test", "path": "/condition", "value": "Excellent"},
+		{"op": "replace", "path": "/condition", "value": "Very Good"}
+	]`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/albums/alb-001", bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var album models.Album
+	err = json.Unmarshal(w.Body.Bytes(), &album)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ConditionVeryGood, album.Condition)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchAlbumJSONPatchConcurrentChange tests that a JSON Patch isn't written when the tested
+// value changes after it was read
+func TestPatchAlbumJSONPatchConcurrentChange(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	expectAlbumLookup(mock, 5, models.ConditionExcellent)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE albums SET rating = $2 WHERE id = $1 AND condition = $3")).
+		WithArgs("alb-001", 4, models.ConditionExcellent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM albums WHERE id = $1)")).
+		WithArgs("alb-001").
+		WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/albums/:id", handler.PatchAlbum)
+
+	patch := `[
+		{"op": "test", "path": "/condition", "value": "Excellent"},
+		{"op": "replace", "path": "/rating", "value": 4}
+	]`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/albums/alb-001", bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "patch_test_failed")
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestPatchAlbumRejected tests patches that must not reach the database
+func TestPatchAlbumRejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"remove title", "application/merge-patch+json", `{"title":null}`, http.StatusBadRequest},
+		{"read-only field", "application/merge-patch+json", `{"id":"alb-002"}`, http.StatusBadRequest},
+		{"wrong type", "application/merge-patch+json", `{"rating":"five"}`, http.StatusBadRequest},
+		{"nested path", "application/json-patch+json", `[{"op":"replace","path":"/artist/name","value":"Floyd"}]`, http.StatusBadRequest},
+		{"failed test", "application/json-patch+json", `[{"op":"test","path":"/rating","value":1}]`, http.StatusConflict},
+		{"unsupported media type", "text/plain", `rating=4`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Set up mock database
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Unable to create mock database connection: %v", err)
+			}
+			defer mock.Close()
+			handler := api.NewHandler(db.NewPostgresStore(mock))
+
+			expectAlbumLookup(mock, 5, models.ConditionExcellent)
+
+			// Set up router
+			router := gin.Default()
+			router.PATCH("/albums/:id", handler.PatchAlbum)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/albums/alb-001", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+
+			// Check expectations
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseAlbumQueryErrors tests that query errors point at the offending token
+func TestParseAlbumQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		column  int
+		token   string
+		message string
+	}{
+		{`artst:"pink floyd"`, 1, "artst", `unknown field "artst"`},
+		{`rating:>=4 year:19x0`, 17, "19x0", "year must be"},
+		{`year:1980..1970`, 6, "1980..1970", "range 1980..1970 is empty"},
+		{`year:..`, 6, "..", "at least one bound"},
+		{`rating:6`, 8, "6", "rating must be"},
+		{`genre:rock condition:mint,scratched`, 27, "scratched", `unknown condition "scratched"`},
+		{`condition:>mint`, 11, ">mint", "no condition is better than Mint"},
+		{`artist:"pink floyd`, 8, `"pink floyd`, "unterminated quoted value"},
+		{`artist:"pink"floyd`, 14, "f", "expected a space"},
+		{`rock genre:`, 6, "genre:", "genre needs a value"},
+		{`genre:rock,`, 11, ",", "needs a value after the comma"},
+		{`rock - jazz`, 6, "-", "must be followed by a term"},
+		{`notes:pink"floyd"`, 11, `"`, "quotes must surround"},
+		{`título:x ünknown:y`, 1, "título", "unknown field"},
+	}
+	for _, tt := range tests {
+		_, err := db.ParseAlbumQuery(tt.query)
+
+		var queryErr *db.AlbumQueryError
+		if assert.True(t, errors.As(err, &queryErr), tt.query) {
+			assert.Equal(t, tt.column, queryErr.Column, tt.query)
+			assert.Equal(t, tt.token, queryErr.Token, tt.query)
+			assert.Contains(t, queryErr.Message, tt.message, tt.query)
+		}
+	}
+
+	for _, query := range []string{``, `  `, `rock`, `-"live at pompeii"`, `Condition:Very_Good,poor RATING:3..`, `year:<1970 notes:"say \"hi\""`} {
+		_, err := db.ParseAlbumQuery(query)
+		assert.NoError(t, err, query)
+	}
+}
+
+// TestGetAlbumsQuery tests that GET /albums?query= compiles the query to parameterized SQL
+func TestGetAlbumsQuery(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	where := regexp.QuoteMeta(`WHERE a.genre_id = $1 AND (LOWER(ar.name) LIKE $2 ESCAPE '\') AND (a.release_year >= $3 AND a.release_year <= $4) AND ` +
+		`(a.rating >= $5) AND (a.condition IN ($6) OR a.condition IN ($7)) AND NOT (LOWER(COALESCE(a.notes, '')) LIKE $8 ESCAPE '\')`)
+	args := []interface{}{"gen-001", "%pink floyd%", "1970", "1979", 4, models.ConditionMint, models.ConditionExcellent, `%100\%%`}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)") + "(?s).*" + where).
+		WithArgs(args...).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+
+	rows := mock.NewRows([]string{"id", "title", "artist_id", "artist_name", "release_year", "genre_id", "genre_name", "genre_icon", "notes", "rating", "condition"}).
+		AddRow("alb-003", "Animals", "art-001", "Pink Floyd", "1977", "gen-001", "Rock", "🎸", "", 4, "Mint")
+	mock.ExpectQuery(where + "(?s).*" + regexp.QuoteMeta("LIMIT $9 OFFSET $10")).
+		WithArgs(append(args, 50, 0)...).
+		WillReturnRows(rows)
+
+	router := gin.Default()
+	router.GET("/albums", handler.GetAlbums)
+
+	query := `artist:"Pink Floyd" year:1970..1979 rating:>=4 condition:mint,excellent -notes:100%`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums?genre_id=gen-001&query="+url.QueryEscape(query), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var page models.AlbumPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 1, page.Total)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestGetAlbumsInvalidQuery tests that album query errors are reported with their position
+func TestGetAlbumsInvalidQuery(t *testing.T) {
+	// The store is never reached, so none is needed
+	handler := api.NewHandler(nil)
+
+	router := gin.Default()
+	router.GET("/albums", handler.GetAlbums)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums?query="+url.QueryEscape("rating:>=4 year:19x0"), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "invalid_query", problem.Code)
+	assert.Contains(t, problem.Detail, `at column 17: "19x0"`)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "query", problem.Errors[0].Field)
+	}
+}
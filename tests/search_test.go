@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearch tests the GET /search endpoint against the Postgres full-text query
+func TestSearch(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	// Only the requested result types are searched
+	albums := regexp.QuoteMeta("WHERE a.search @@ query OR $1 <% a.title")
+	artists := regexp.QuoteMeta("WHERE ar.search @@ query OR $1 <% ar.name")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM") + "(?s).*" + albums + "(?s).*UNION ALL(?s).*" + artists).
+		WithArgs("pink floyd").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+
+	rows := mock.NewRows([]string{"type", "id", "name", "snippet", "score"}).
+		AddRow("artist", "art-001", "Pink Floyd", "<mark>Pink</mark> <mark>Floyd</mark>", 1.6).
+		AddRow("album", "alb-001", "The Dark Side of the Moon", "The Dark Side of the Moon · <mark>Pink</mark> <mark>Floyd</mark>", 0.4)
+	mock.ExpectQuery(regexp.QuoteMeta("ts_headline('english'")+"(?s).*"+albums+"(?s).*"+artists+"(?s).*"+
+		regexp.QuoteMeta("ORDER BY score DESC, type, id")+"(?s).*"+regexp.QuoteMeta("LIMIT $2 OFFSET $3")).
+		WithArgs("pink floyd", 2, 0).
+		WillReturnRows(rows)
+
+	router := gin.Default()
+	router.GET("/search", handler.Search)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/search?q=+pink+floyd+&type=album,artist&limit=2", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var page models.SearchPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 3, page.Total)
+	if assert.Len(t, page.Items, 2) {
+		assert.Equal(t, "/artists/art-001", page.Items[0].Href)
+		assert.Equal(t, "/albums/alb-001", page.Items[1].Href)
+		assert.Equal(t, "<mark>Pink</mark> <mark>Floyd</mark>", page.Items[0].Snippet)
+	}
+	if assert.NotNil(t, page.NextOffset) {
+		assert.Equal(t, 2, *page.NextOffset)
+	}
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestSearchInvalidParams tests that bad search parameters are rejected before hitting the database
+func TestSearchInvalidParams(t *testing.T) {
+	// The store is never reached, so none is needed
+	handler := api.NewHandler(nil)
+
+	router := gin.Default()
+	router.GET("/search", handler.Search)
+
+	for _, query := range []string{"", "q=++", "q=rock&type=label", "q=rock&limit=500", "q=rock&offset=-1", "q=rock&limit=abc"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/search?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/seed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSeedFixtures tests that every embedded fixture set loads, and that loading it twice is harmless
+func TestSeedFixtures(t *testing.T) {
+	for _, name := range seed.Names() {
+		t.Run(name, func(t *testing.T) {
+			fixture, err := seed.Load(name)
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			store := db.NewMemoryStore()
+			result, err := seed.Apply(ctx, store, fixture)
+			require.NoError(t, err)
+			assert.Equal(t, len(fixture.Artists)+len(fixture.Genres)+len(fixture.Albums), result.Created)
+			assert.Zero(t, result.Skipped)
+
+			result, err = seed.Apply(ctx, store, fixture)
+			require.NoError(t, err)
+			assert.Zero(t, result.Created)
+		})
+	}
+
+	_, err := seed.Load("nope")
+	assert.Error(t, err)
+}
+
+// TestSeedSynthetic tests generating synthetic albums for load testing
+func TestSeedSynthetic(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	_, err := seed.Apply(ctx, store, seed.Synthetic(25, 42))
+	require.NoError(t, err)
+
+	page, err := store.GetAlbums(ctx, db.AlbumFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 25, page.Total)
+
+	// The same seed generates the same albums, so a larger set only adds the missing ones
+	assert.Equal(t, seed.Synthetic(25, 42).Albums, seed.Synthetic(40, 42).Albums[:25])
+	result, err := seed.Apply(ctx, store, seed.Synthetic(40, 42))
+	require.NoError(t, err)
+	assert.Equal(t, 15+1, result.Created) // 15 albums and the fourth artist
+
+	page, err = store.GetAlbums(ctx, db.AlbumFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 40, page.Total)
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/config"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that keeps the finished spans in memory
+// and restores the previous provider when the test ends
+func recordSpans(t *testing.T) (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	_, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: "none"})
+	require.NoError(t, err)
+	return recorder, provider
+}
+
+// spanAttribute returns the value of a span attribute
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+// TestTraceparentPropagation tests that the server span continues the caller's trace
+// and that the request's log lines carry the trace ID
+func TestTraceparentPropagation(t *testing.T) {
+	recorder, provider := recordSpans(t)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(otelgin.Middleware("vinylvault", otelgin.WithTracerProvider(provider)))
+	router.Use(api.RequestID(slog.New(slog.NewJSONHandler(&logs, nil))), api.AccessLog())
+	api.RegisterRoutes(router, db.NewMemoryStore())
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentID = "00f067aa0ba902b7"
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/albums/alb-001", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	router.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "/albums/:id", spans[0].Name())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, parentID, spans[0].Parent().SpanID().String())
+
+	lines := logLines(t, &logs)
+	require.Len(t, lines, 1)
+	assert.Equal(t, traceID, lines[0]["trace_id"])
+	assert.Equal(t, spans[0].SpanContext().SpanID().String(), lines[0]["span_id"])
+}
+
+// TestQuerySpans tests that queries become client spans under the request's span
+func TestQuerySpans(t *testing.T) {
+	recorder, provider := recordSpans(t)
+	tracer := tracing.QueryTracer()
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "GET /albums")
+
+	queryCtx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL:  "\n\t\tSELECT COUNT(*)\n\t\tFROM albums a\n\t\tWHERE a.rating >= $1",
+		Args: []any{4},
+	})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{})
+
+	queryCtx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "INSERT INTO artists (id, name) VALUES ($1, $2)"})
+	tracer.TraceQueryEnd(queryCtx, nil, pgx.TraceQueryEndData{Err: errors.New("duplicate key")})
+	request.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	count, insert := spans[0], spans[1]
+	assert.Equal(t, "select albums", count.Name())
+	assert.Equal(t, trace.SpanKindClient, count.SpanKind())
+	assert.Equal(t, request.SpanContext().SpanID(), count.Parent().SpanID())
+	assert.Equal(t, "postgresql", spanAttribute(count, "db.system"))
+	assert.Equal(t, "select", spanAttribute(count, "db.operation.name"))
+	assert.Equal(t, "albums", spanAttribute(count, "db.collection.name"))
+	assert.Contains(t, spanAttribute(count, "db.query.text"), "WHERE a.rating >= $1")
+	assert.Equal(t, codes.Unset, count.Status().Code)
+
+	assert.Equal(t, "insert artists", insert.Name())
+	assert.Equal(t, codes.Error, insert.Status().Code)
+	assert.Equal(t, "duplicate key", insert.Status().Description)
+}
+
+// TestTracingSetup tests creating the configured exporters
+func TestTracingSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	for _, exporter := range []string{"none", "stdout", "otlp"} {
+		shutdown, err := tracing.Setup(context.Background(), config.TracingConfig{
+			Exporter:    exporter,
+			Endpoint:    "127.0.0.1:4318",
+			Insecure:    true,
+			SampleRatio: 1,
+			ServiceName: "vinylvault-test",
+		})
+		require.NoError(t, err, exporter)
+		assert.NoError(t, shutdown(context.Background()), exporter)
+	}
+
+	_, err := config.Load(writeConfig(t, minimalConfig+`
+tracing:
+  exporter: jaeger
+  endpoint: http://collector:4318
+  sample_ratio: 1.5
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tracing.exporter: "jaeger" must be none, otlp or stdout`)
+	assert.Contains(t, err.Error(), `tracing.endpoint: "http://collector:4318" must be a host:port without a scheme`)
+	assert.Contains(t, err.Error(), "tracing.sample_ratio: must be above 0 and at most 1, got 1.5")
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emirhanalptekin/vinylvault/internal/api"
+	"github.com/emirhanalptekin/vinylvault/internal/db"
+	"github.com/emirhanalptekin/vinylvault/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateAlbumValidation tests that every invalid album field is reported at once
+func TestCreateAlbumValidation(t *testing.T) {
+	// Set up mock database; no queries are expected
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	// Set up router
+	router := gin.Default()
+	router.POST("/albums", handler.CreateAlbum)
+
+	body := `{
+		"title": "   ",
+		"artist_id": "art-001",
+		"genre_id": "gen-001",
+		"release_year": "nineteen-seventy",
+		"rating": 7,
+		"condition": "Scratched"
+	}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/albums", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response models.Problem
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+
+	fields := map[string]string{}
+	for _, fieldErr := range response.Errors {
+		fields[fieldErr.Field] = fieldErr.Message
+	}
+	assert.Equal(t, "must not be blank", fields["title"])
+	assert.Contains(t, fields["release_year"], "must be a year between 1948")
+	assert.Equal(t, "must be at most 5", fields["rating"])
+	assert.Contains(t, fields["condition"], "must be one of Mint")
+	assert.Len(t, fields, 4)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// TestCreateAlbumMalformedBody tests that bodies which aren't valid JSON are still a 400
+func TestCreateAlbumMalformedBody(t *testing.T) {
+	// The store is never reached, so none is needed
+	handler := api.NewHandler(nil)
+
+	router := gin.Default()
+	router.POST("/albums", handler.CreateAlbum)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/albums", bytes.NewBufferString(`{"title":`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestPatchAlbumValidation tests that patched fields are validated too
+func TestPatchAlbumValidation(t *testing.T) {
+	// Set up mock database
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Unable to create mock database connection: %v", err)
+	}
+	defer mock.Close()
+	handler := api.NewHandler(db.NewPostgresStore(mock))
+
+	expectAlbumLookup(mock, 5, models.ConditionExcellent)
+
+	// Set up router
+	router := gin.Default()
+	router.PATCH("/albums/:id", handler.PatchAlbum)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/albums/alb-001", bytes.NewBufferString(`{"rating":0,"release_year":"1899"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response models.Problem
+	err = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Errors, 2)
+
+	// Check expectations
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}